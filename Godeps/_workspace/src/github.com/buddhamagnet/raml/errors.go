@@ -31,6 +31,7 @@ package raml
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	yaml "github.com/buddhamagnet/yaml"
@@ -39,29 +40,116 @@ import (
 // A RamlError is returned by the ParseFile function when RAML or YAML problems
 // are encountered when parsing the RAML document.
 type RamlError struct {
+	// Errors holds a description of each of the Problems.
 	Errors []string
+
+	Problems []*Problem
+
+	// validation is set for errors returned by Validate.
+	validation bool
 }
 
 func (e *RamlError) Error() string {
-	return fmt.Sprintf("Error parsing RAML:\n  %s\n",
+	what := "Error parsing RAML"
+	if e.validation {
+		what = "Invalid RAML"
+	}
+	return fmt.Sprintf("%s:\n  %s\n", what,
 		strings.Join(e.Errors, "\n  "))
 }
 
-// Populate the RAML error value with converted YAML error strings (with
+// add appends a problem to the error.
+func (e *RamlError) add(p *Problem) {
+	e.Problems = append(e.Problems, p)
+	e.Errors = append(e.Errors, p.String())
+}
+
+// sort orders the problems by file, line and column, then path and
+// message, so they are reported the same way every time.
+func (e *RamlError) sort() {
+	sort.SliceStable(e.Problems, func(i, j int) bool {
+		a, b := e.Problems[i], e.Problems[j]
+		switch {
+		case a.File != b.File:
+			return a.File < b.File
+		case a.Line != b.Line:
+			return a.Line < b.Line
+		case a.Column != b.Column:
+			return a.Column < b.Column
+		}
+		if pa, pb := strings.Join(a.Path, "\x00"), strings.Join(b.Path, "\x00"); pa != pb {
+			return pa < pb
+		}
+		return a.Message < b.Message
+	})
+	for i, p := range e.Problems {
+		e.Errors[i] = p.String()
+	}
+}
+
+// A Problem describes a single problem with a RAML document and where it
+// was found. Positions refer to the file the problem is in, which may have
+// been pulled in via !include.
+type Problem struct {
+	// File is the path of the file, empty for documents parsed from bytes.
+	File string
+
+	// Line and Column are 1-based, or zero if the position is unknown.
+	Line   int
+	Column int
+
+	// Path holds the keys leading to the problem, for example
+	// ["/users/{id}", "get", "responses", "200"].
+	Path []string
+
+	Message string
+}
+
+func (p *Problem) String() string {
+	var parts []string
+	position := p.File
+	if p.Line != 0 {
+		position = strings.TrimPrefix(fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column), ":")
+	}
+	if position != "" {
+		parts = append(parts, position)
+	}
+	if len(p.Path) != 0 {
+		parts = append(parts, strings.Join(p.Path, " "))
+	}
+	return strings.Join(append(parts, p.Message), ": ")
+}
+
+// problemAt returns a problem found at a position in a YAML document.
+func problemAt(mark yaml.Mark, message string) *Problem {
+	return &Problem{
+		File:    mark.Doc,
+		Line:    mark.Line,
+		Column:  mark.Column,
+		Path:    mark.Path,
+		Message: message,
+	}
+}
+
+// Populate the RAML error value with converted YAML errors (with
 // additional context)
 func populateRAMLError(ramlError *RamlError,
 	yamlErrors *yaml.TypeError) {
 
 	// Go over the errors
-	for _, currErr := range yamlErrors.Errors {
+	for i, currErr := range yamlErrors.Errors {
 
-		// Create the RAML errors
-		ramlError.Errors =
-			append(ramlError.Errors, convertYAMLError(currErr))
+		// Create the RAML errors, unpositioned if the error has no mark
+		var mark yaml.Mark
+		if i < len(yamlErrors.Marks) {
+			mark = yamlErrors.Marks[i]
+		}
+		ramlError.add(problemAt(mark, convertYAMLError(currErr)))
 	}
 }
 
-// Convert a YAML error string into RAML error string, with more context
+// Convert a YAML error string into RAML error string, with more context.
+// Positions are not included as they're held in the error's yaml.Mark.
 func convertYAMLError(yamlError string) string {
 
 	if strings.Contains(yamlError, "cannot unmarshal") {
@@ -70,14 +158,10 @@ func convertYAMLError(yamlError string) string {
 
 		if len(yamlErrorParts) >= 7 {
 
-			fmt.Println(yamlError)
-
 			var ok bool
 			var source string
 			var target string
 			var targetName string
-			line := yamlErrorParts[1]
-			line = line[:len(line)-1]
 
 			// TODO: support more complex types:
 			// map[string]raml.NamedParameter -->
@@ -97,7 +181,6 @@ func convertYAMLError(yamlError string) string {
 			if source, ok = yamlTypeToName[yamlErrorParts[4]]; !ok {
 				source = yamlErrorParts[4]
 			}
-			fmt.Println("source: ", source)
 
 			if source == "string" {
 				source = fmt.Sprintf("string (got %s)", yamlErrorParts[5])
@@ -112,13 +195,16 @@ func convertYAMLError(yamlError string) string {
 
 			target, _ = ramlTypes[target]
 
-			return fmt.Sprintf("line %s: %s cannot be of "+
-				"type %s, must be %s", line, targetName, source, target)
+			return fmt.Sprintf("%s cannot be of "+
+				"type %s, must be %s", targetName, source, target)
 
 		}
 	}
 
-	// Otherwise
+	// Otherwise, dropping the line number as it's held separately
+	if i := strings.Index(yamlError, ": "); strings.HasPrefix(yamlError, "line ") && i != -1 {
+		yamlError = yamlError[i+2:]
+	}
 	return fmt.Sprintf("YAML error, %s", yamlError)
 }
 
@@ -146,6 +232,7 @@ var ramlTypeNames map[string]string = map[string]string{
 	"raml.Body":                 "body",
 	"raml.Response":             "response",
 	"raml.DefinitionParameters": "definition parameters",
+	"raml.DefinitionParameter":  "definition parameter",
 	"raml.DefinitionChoice":     "definition choice",
 	"raml.Trait":                "trait",
 	"raml.ResourceTypeMethod":   "resource type method",
@@ -168,6 +255,7 @@ var ramlTypes map[string]string = map[string]string{
 	"raml.Body":                 "mapping",
 	"raml.Response":             "mapping",
 	"raml.DefinitionParameters": "mapping",
+	"raml.DefinitionParameter":  "scalar or sequence",
 	"raml.DefinitionChoice":     "string or mapping",
 	"raml.Trait":                "mapping",
 	"raml.ResourceTypeMethod":   "mapping",
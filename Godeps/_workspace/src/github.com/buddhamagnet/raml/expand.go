@@ -0,0 +1,348 @@
+// Copyright 2014 DoAT. All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without modification,
+// are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice,
+//    this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation and/or
+//    other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED “AS IS” WITHOUT ANY WARRANTIES WHATSOEVER.
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO,
+// THE IMPLIED WARRANTIES OF NON INFRINGEMENT, MERCHANTABILITY AND FITNESS FOR A
+// PARTICULAR PURPOSE ARE HEREBY DISCLAIMED. IN NO EVENT SHALL DoAT OR CONTRIBUTORS
+// BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// // THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING
+// NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE,
+// EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+// The views and conclusions contained in the software and documentation are those of
+// the authors and should not be interpreted as representing official policies,
+// either expressed or implied, of DoAT.
+
+package raml
+
+// This file contains the code that applies resource types and traits.
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Matches trait and resource type parameters, e.g. <<resourcePathName>>
+var templateParameter = regexp.MustCompile(`<<\s*([^>\s|]+)\s*(?:\|\s*!(singularize|pluralize)\s*)?>>`)
+
+// Expand applies resource types and traits to the resources and methods of
+// an API definition, substituting their parameters, so that each method
+// carries everything that applies to it. Properties declared directly on a
+// resource or method take precedence over those it inherits. References to
+// undefined resource types and traits are ignored; Validate reports them.
+func Expand(api *APIDefinition) {
+	traits := make(map[string]Trait)
+	for _, t := range api.Traits {
+		for name, trait := range t {
+			traits[name] = trait
+		}
+	}
+	resourceTypes := make(map[string]ResourceType)
+	for _, t := range api.ResourceTypes {
+		for name, resourceType := range t {
+			resourceTypes[name] = resourceType
+		}
+	}
+
+	e := &expander{traits: traits, resourceTypes: resourceTypes}
+	for uri, resource := range api.Resources {
+		e.resource(uri, &resource)
+		api.Resources[uri] = resource
+	}
+}
+
+// Expanded returns a copy of an API definition with resource types and
+// traits applied as Expand applies them, leaving the original unchanged.
+func Expanded(api *APIDefinition) *APIDefinition {
+	c := api.Copy()
+	Expand(c)
+	return c
+}
+
+// Copy returns a deep copy of an API definition, which can be changed, by
+// Expand for example, without changing the original.
+func (r *APIDefinition) Copy() *APIDefinition {
+	c := deepCopy(reflect.ValueOf(r), identity).Interface().(*APIDefinition)
+	// What the parser recorded about the document never changes, so is
+	// shared
+	c.marks, c.unknown, c.source, c.included = r.marks, r.unknown, r.source, r.included
+	return c
+}
+
+type expander struct {
+	traits        map[string]Trait
+	resourceTypes map[string]ResourceType
+}
+
+func (e *expander) resource(uri string, r *Resource) {
+	params := map[string]string{
+		"resourcePath":     uri,
+		"resourcePathName": resourcePathName(uri),
+	}
+
+	// Properties are inherited in order of precedence: the method's traits,
+	// the resource's traits and then the resource type. As values already
+	// set are never overwritten, they are merged in that order.
+	rt := e.resourceType(r.Type, params, make(map[string]bool))
+
+	type typeMethod struct {
+		method             **Method
+		name               string
+		required, optional *ResourceTypeMethod
+	}
+	var typeMethods []typeMethod
+	if rt != nil {
+		typeMethods = []typeMethod{
+			{&r.Get, "GET", rt.Get, rt.OptionalGet},
+			{&r.Head, "HEAD", rt.Head, rt.OptionalHead},
+			{&r.Post, "POST", rt.Post, rt.OptionalPost},
+			{&r.Put, "PUT", rt.Put, rt.OptionalPut},
+			{&r.Delete, "DELETE", rt.Delete, rt.OptionalDelete},
+			{&r.Patch, "PATCH", rt.Patch, rt.OptionalPatch},
+			{&r.Options, "OPTIONS", rt.Options, rt.OptionalOptions},
+			{&r.Trace, "TRACE", rt.Trace, rt.OptionalTrace},
+			{&r.Connect, "CONNECT", rt.Connect, rt.OptionalConnect},
+		}
+		// Methods declared by the resource type, unless optional, are
+		// added to the resource.
+		for _, m := range typeMethods {
+			if *m.method == nil && m.required != nil {
+				*m.method = &Method{Name: m.name}
+			}
+		}
+	}
+
+	for _, m := range r.Methods() {
+		mparams := withParams(params, map[string]string{"methodName": strings.ToLower(m.Name)})
+		for _, is := range append(append([]DefinitionChoice(nil), m.Is...), r.Is...) {
+			if trait, ok := e.traits[is.Name]; ok {
+				trait = substitute(trait, withParams(mparams, is.Parameters.values())).(Trait)
+				applyTrait(m, &trait)
+			}
+		}
+	}
+
+	if rt != nil {
+		merge(&r.Description, rt.Description)
+		merge(&r.UriParameters, rt.UriParameters)
+		merge(&r.BaseUriParameters, rt.BaseUriParameters)
+		mergeOptional(&r.UriParameters, rt.OptionalUriParameters)
+		mergeOptional(&r.BaseUriParameters, rt.OptionalBaseUriParameters)
+		for _, m := range typeMethods {
+			if *m.method == nil {
+				continue
+			}
+			mparams := withParams(params, map[string]string{"methodName": strings.ToLower(m.name)})
+			for _, rtm := range []*ResourceTypeMethod{m.required, m.optional} {
+				if rtm != nil {
+					rtm := substitute(*rtm, mparams).(ResourceTypeMethod)
+					applyResourceTypeMethod(*m.method, &rtm)
+				}
+			}
+		}
+	}
+
+	for nested, child := range r.Nested {
+		e.resource(uri+nested, child)
+	}
+}
+
+// resourceType returns the resource type chosen, with its parameters
+// substituted and the resource types it inherits from applied, or nil if
+// it's undefined. Inheritance cycles are broken at the first repeat.
+func (e *expander) resourceType(choice *DefinitionChoice, params map[string]string, seen map[string]bool) *ResourceType {
+	if choice == nil || seen[choice.Name] {
+		return nil
+	}
+	t, ok := e.resourceTypes[choice.Name]
+	if !ok {
+		return nil
+	}
+	seen[choice.Name] = true
+	t = substitute(t, withParams(params, choice.Parameters.values())).(ResourceType)
+	if parent := e.resourceType(t.Type, params, seen); parent != nil {
+		merge(&t, *parent)
+	}
+	return &t
+}
+
+func applyResourceTypeMethod(m *Method, rtm *ResourceTypeMethod) {
+	merge(&m.Description, rtm.Description)
+	merge(&m.Bodies, rtm.Bodies)
+	merge(&m.Headers, rtm.Headers)
+	merge(&m.Responses, rtm.Responses)
+	merge(&m.QueryParameters, rtm.QueryParameters)
+	merge(&m.Protocols, rtm.Protocols)
+}
+
+func applyTrait(m *Method, t *Trait) {
+	merge(&m.Description, t.Description)
+	merge(&m.Bodies, t.Bodies)
+	merge(&m.Headers, t.Headers)
+	merge(&m.Responses, t.Responses)
+	merge(&m.QueryParameters, t.QueryParameters)
+	merge(&m.Protocols, t.Protocols)
+	mergeOptional(&m.Bodies, t.OptionalBodies)
+	mergeOptional(&m.Headers, t.OptionalHeaders)
+	mergeOptional(&m.Responses, t.OptionalResponses)
+	mergeOptional(&m.QueryParameters, t.OptionalQueryParameters)
+}
+
+// resourcePathName returns the rightmost segment of a URI that isn't a
+// URI parameter, as defined by the RAML spec.
+func resourcePathName(uri string) string {
+	segments := strings.Split(uri, "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if s := segments[i]; s != "" && !strings.HasPrefix(s, "{") {
+			return s
+		}
+	}
+	return ""
+}
+
+func withParams(params map[string]string, extra map[string]string) map[string]string {
+	merged := make(map[string]string, len(params)+len(extra))
+	for k, v := range params {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
+
+// substitute returns a deep copy of v with template parameters replaced
+// in every string and map key.
+func substitute(v interface{}, params map[string]string) interface{} {
+	replace := func(s string) string {
+		return templateParameter.ReplaceAllStringFunc(s, func(p string) string {
+			m := templateParameter.FindStringSubmatch(p)
+			value, ok := params[m[1]]
+			if !ok {
+				return p
+			}
+			switch m[2] {
+			case "singularize":
+				return strings.TrimSuffix(value, "s")
+			case "pluralize":
+				if !strings.HasSuffix(value, "s") {
+					return value + "s"
+				}
+			}
+			return value
+		})
+	}
+	return deepCopy(reflect.ValueOf(v), replace).Interface()
+}
+
+func deepCopy(v reflect.Value, replace func(string) string) reflect.Value {
+	out := reflect.New(v.Type()).Elem()
+	switch v.Kind() {
+	case reflect.String:
+		out.SetString(replace(v.String()))
+	case reflect.Ptr:
+		if !v.IsNil() {
+			out.Set(reflect.New(v.Type().Elem()))
+			out.Elem().Set(deepCopy(v.Elem(), replace))
+		}
+	case reflect.Interface:
+		if !v.IsNil() {
+			out.Set(deepCopy(v.Elem(), replace))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if out.Field(i).CanSet() {
+				out.Field(i).Set(deepCopy(v.Field(i), replace))
+			}
+		}
+	case reflect.Slice:
+		if !v.IsNil() {
+			out.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Len()))
+			for i := 0; i < v.Len(); i++ {
+				out.Index(i).Set(deepCopy(v.Index(i), replace))
+			}
+		}
+	case reflect.Map:
+		if !v.IsNil() {
+			out.Set(reflect.MakeMapWithSize(v.Type(), v.Len()))
+			for _, k := range v.MapKeys() {
+				out.SetMapIndex(deepCopy(k, replace), deepCopy(v.MapIndex(k), replace))
+			}
+		}
+	default:
+		out.Set(v)
+	}
+	return out
+}
+
+// merge fills the zero parts of *dst with src, recursing into structs and
+// maps so that values already in dst take precedence.
+func merge(dst interface{}, src interface{}) {
+	mergeValue(reflect.ValueOf(dst).Elem(), reflect.ValueOf(src))
+}
+
+// mergeOptional is like merge for optional properties, such as body?, which
+// only apply where dst already has a value.
+func mergeOptional(dst interface{}, src interface{}) {
+	if !isZero(reflect.ValueOf(dst).Elem()) {
+		merge(dst, src)
+	}
+}
+
+func mergeValue(dst, src reflect.Value) {
+	if isZero(src) {
+		return
+	}
+	if isZero(dst) {
+		dst.Set(deepCopy(src, identity))
+		return
+	}
+	switch dst.Kind() {
+	case reflect.Ptr:
+		mergeValue(dst.Elem(), src.Elem())
+	case reflect.Struct:
+		for i := 0; i < dst.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				mergeValue(dst.Field(i), src.Field(i))
+			}
+		}
+	case reflect.Map:
+		for _, k := range src.MapKeys() {
+			existing := dst.MapIndex(k)
+			if !existing.IsValid() {
+				dst.SetMapIndex(k, deepCopy(src.MapIndex(k), identity))
+				continue
+			}
+			// Map values aren't addressable, so merge into a copy
+			v := reflect.New(existing.Type()).Elem()
+			v.Set(existing)
+			mergeValue(v, src.MapIndex(k))
+			dst.SetMapIndex(k, v)
+		}
+	}
+}
+
+func identity(s string) string {
+	return s
+}
+
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
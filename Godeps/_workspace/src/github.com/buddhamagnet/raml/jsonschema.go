@@ -0,0 +1,199 @@
+// Copyright 2014 DoAT. All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without modification,
+// are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice,
+//    this list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation and/or
+//    other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED “AS IS” WITHOUT ANY WARRANTIES WHATSOEVER.
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO,
+// THE IMPLIED WARRANTIES OF NON INFRINGEMENT, MERCHANTABILITY AND FITNESS FOR A
+// PARTICULAR PURPOSE ARE HEREBY DISCLAIMED. IN NO EVENT SHALL DoAT OR CONTRIBUTORS
+// BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY
+// // THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING
+// NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE,
+// EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+//
+// The views and conclusions contained in the software and documentation are those of
+// the authors and should not be interpreted as representing official policies,
+// either expressed or implied, of DoAT.
+
+package raml
+
+// This file contains a minimal JSON schema validator, used to check that
+// examples match their schemas.
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+)
+
+// validateJSON checks a value decoded from JSON against a JSON schema and
+// returns a description of each mismatch, prefixed with the location of
+// the offending value. Only the commonly used draft 3 and draft 4 keywords
+// are supported; references and unknown keywords are ignored.
+func validateJSON(schema map[string]interface{}, value interface{}, at string) []string {
+	var mismatches []string
+	fail := func(format string, args ...interface{}) {
+		mismatches = append(mismatches, at+": "+fmt.Sprintf(format, args...))
+	}
+
+	if t, ok := schema["type"]; ok && !matchesType(t, value) {
+		fail("expected %v, got %s", t, jsonType(value))
+		return mismatches
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("%v is not one of %v", value, enum)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		required := make(map[string]bool)
+		if list, ok := schema["required"].([]interface{}); ok {
+			for _, name := range list {
+				required[fmt.Sprint(name)] = true
+			}
+		}
+		for _, name := range sortedKeys(properties) {
+			property, _ := properties[name].(map[string]interface{})
+			if property == nil {
+				continue
+			}
+			if r, ok := property["required"].(bool); ok && r {
+				required[name] = true
+			}
+			if pv, ok := v[name]; ok {
+				mismatches = append(mismatches, validateJSON(property, pv, at+"/"+name)...)
+			}
+		}
+		for _, name := range sortedKeys(required) {
+			if _, ok := v[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+			for _, name := range sortedKeys(v) {
+				if _, ok := properties[name]; !ok {
+					fail("unexpected property %q", name)
+				}
+			}
+		}
+	case []interface{}:
+		if n, ok := schema["minItems"].(float64); ok && float64(len(v)) < n {
+			fail("has fewer than %v items", n)
+		}
+		if n, ok := schema["maxItems"].(float64); ok && float64(len(v)) > n {
+			fail("has more than %v items", n)
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				mismatches = append(mismatches, validateJSON(items, item, fmt.Sprintf("%s/%d", at, i))...)
+			}
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(v))
+		if n, ok := schema["minLength"].(float64); ok && length < n {
+			fail("%q is shorter than %v", v, n)
+		}
+		if n, ok := schema["maxLength"].(float64); ok && length > n {
+			fail("%q is longer than %v", v, n)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(v) {
+				fail("%q does not match pattern %s", v, pattern)
+			}
+		}
+	case float64:
+		if n, ok := schema["minimum"].(float64); ok && v < n {
+			fail("%v is less than %v", v, n)
+		}
+		if n, ok := schema["maximum"].(float64); ok && v > n {
+			fail("%v is greater than %v", v, n)
+		}
+	}
+
+	return mismatches
+}
+
+// matchesType reports whether a value has one of the types named by a
+// schema's type keyword, which may be a string or a list of strings.
+func matchesType(t interface{}, value interface{}) bool {
+	switch t := t.(type) {
+	case string:
+		switch t {
+		case "any":
+			return true
+		case "integer":
+			n, ok := value.(float64)
+			return ok && n == math.Trunc(n)
+		case "number":
+			_, ok := value.(float64)
+			return ok
+		}
+		return t == jsonType(value)
+	case []interface{}:
+		for _, alt := range t {
+			if matchesType(alt, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// jsonType returns the JSON schema type name of a decoded JSON value.
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func jsonEqual(a, b interface{}) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return string(ja) == string(jb)
+}
@@ -30,12 +30,12 @@ package raml
 // This file contains all of the RAML parser related code.
 
 import (
-	"bufio"
 	"bytes"
 	"errors"
 	"fmt"
-	"io"
-	"io/ioutil"
+	"io/fs"
+	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -47,16 +47,40 @@ import (
 // This is the main entry point to the RAML parser.
 func ParseFile(filePath string) (*APIDefinition, error) {
 
-	// Get the working directory
-	workingDirectory, fileName := filepath.Split(filePath)
+	// Files are named relative to the working directory, so that problems
+	// are reported against paths the caller recognises.
+	return ParseFS(dirFS(""), filepath.ToSlash(filePath))
+}
+
+// ParseFS parses the named RAML file in the file system fsys. Files
+// referenced via !include are read from fsys, relative to the directory
+// of the including file.
+func ParseFS(fsys fs.FS, name string) (*APIDefinition, error) {
 
 	// Read original file contents into a byte array
-	mainFileBytes, err := readFileContents(workingDirectory, fileName)
+	mainFileBytes, err := readFileContents(fsys, name)
 
 	if err != nil {
 		return nil, err
 	}
 
+	return parse(mainFileBytes, fsys, name)
+}
+
+// ParseBytes parses a RAML document held in memory. Files referenced via
+// !include are read from fsys, or the working directory if fsys is nil.
+func ParseBytes(contents []byte, fsys fs.FS) (*APIDefinition, error) {
+	if fsys == nil {
+		fsys = dirFS(".")
+	}
+
+	return parse(contents, fsys, "")
+}
+
+// parse parses the contents of the RAML document called name, resolving
+// includes relative to it in fsys.
+func parse(mainFileBytes []byte, fsys fs.FS, name string) (*APIDefinition, error) {
+
 	// Get the contents of the main file
 	mainFileBuffer := bytes.NewBuffer(mainFileBytes)
 
@@ -81,23 +105,26 @@ func ParseFile(filePath string) (*APIDefinition, error) {
 		}
 	}
 
-	// Pre-process the original file, following !include directive
-	preprocessedContentsBytes, err :=
-		preProcess(mainFileBuffer, workingDirectory)
-
-	if err != nil {
-		return nil,
-			fmt.Errorf("Error preprocessing RAML file (Error: %s)", err.Error())
-	}
-
-	//pretty.Println(string(preprocessedContentsBytes))
-
 	// Unmarshal into an APIDefinition value
 	apiDefinition := new(APIDefinition)
 	apiDefinition.RAMLVersion = ramlVersion
 
-	// Go!
-	err = yaml.Unmarshal(preprocessedContentsBytes, apiDefinition)
+	// Go! Following !include directives as we go, and noting where
+	// everything is for the validator.
+	apiDefinition.marks = make(map[string]yaml.Mark)
+	decoder := &yaml.Decoder{
+		Tags: map[string]yaml.TagHandler{"!include": includer(fsys, func(name string) {
+			apiDefinition.included = append(apiDefinition.included, name)
+		})},
+		Visit: func(mark yaml.Mark, unknown bool) {
+			if unknown {
+				apiDefinition.unknown = append(apiDefinition.unknown, mark)
+				return
+			}
+			apiDefinition.marks[markKey(mark.Path)] = mark
+		},
+	}
+	err := decoder.Unmarshal(name, mainFileBytes, apiDefinition)
 
 	// Any errors?
 	if err != nil {
@@ -108,15 +135,19 @@ func ParseFile(filePath string) (*APIDefinition, error) {
 		// Copy the YAML errors into it..
 		if yamlErrors, ok := err.(*yaml.TypeError); ok {
 			populateRAMLError(ramlError, yamlErrors)
+		} else if docError, ok := err.(*yaml.DocumentError); ok {
+			// Syntax errors and failed includes
+			ramlError.add(problemAt(docError.Mark, docError.Message))
 		} else {
 			// Or just any other error, though this shouldn't happen.
-			ramlError.Errors = append(ramlError.Errors, err.Error())
+			ramlError.add(&Problem{File: name, Message: err.Error()})
 		}
 
 		return nil, ramlError
 	}
 
 	postProcess(apiDefinition)
+	apiDefinition.source = &source{fsys, name, mainFileBytes}
 
 	// Good.
 	return apiDefinition, nil
@@ -147,23 +178,38 @@ func addMethodNames(r *Resource) {
 	if r.Delete != nil {
 		r.Delete.Name = "DELETE"
 	}
+	if r.Options != nil {
+		r.Options.Name = "OPTIONS"
+	}
+	if r.Trace != nil {
+		r.Trace.Name = "TRACE"
+	}
+	if r.Connect != nil {
+		r.Connect.Name = "CONNECT"
+	}
 
 	for _, n := range r.Nested {
 		addMethodNames(n)
 	}
 }
 
-// Reads the contents of a file, returns a bytes buffer
-func readFileContents(workingDirectory string, fileName string) ([]byte, error) {
+// dirFS is a file system rooted at a directory on disk. Unlike os.DirFS
+// it allows includes to refer to files outside of the directory.
+type dirFS string
 
-	filePath := filepath.Join(workingDirectory, fileName)
+func (dir dirFS) Open(name string) (fs.File, error) {
+	return os.Open(filepath.Join(string(dir), filepath.FromSlash(name)))
+}
+
+// Reads the contents of a file, returns a bytes buffer
+func readFileContents(fsys fs.FS, filePath string) ([]byte, error) {
 
-	if fileName == "" {
+	if filePath == "" || filePath == "." {
 		return nil, fmt.Errorf("File name cannot be nil: %s", filePath)
 	}
 
 	// Read the file
-	fileContentsArray, err := ioutil.ReadFile(filePath)
+	fileContentsArray, err := fs.ReadFile(fsys, filePath)
 	if err != nil {
 		return nil,
 			fmt.Errorf("Could not read file %s (Error: %s)",
@@ -173,87 +219,31 @@ func readFileContents(workingDirectory string, fileName string) ([]byte, error)
 	return fileContentsArray, nil
 }
 
-// preProcess acts as a preprocessor for a RAML document in YAML format,
-// including files referenced via !include. It returns a pre-processed document.
-func preProcess(originalContents io.Reader, workingDirectory string) ([]byte, error) {
-
-	// NOTE: Since YAML doesn't support !include directives, and since go-yaml
-	// does NOT play nice with !include tags, this has to be done like this.
-	// I am considering modifying go-yaml to add custom handlers for specific
-	// tags, to add support for !include, but for now - this method is
-	// GoodEnough(TM) and since it will only happen once, I am not prematurely
-	// optimizing it.
-
-	var preprocessedContents bytes.Buffer
-
-	// Go over each line, looking for !include tags
-	scanner := bufio.NewScanner(originalContents)
-	var line string
-
-	// Scan the file until we reach EOF or error out
-	for scanner.Scan() {
-		line = scanner.Text()
-
-		// Did we find an !include directive to handle?
-		if idx := strings.Index(line, "!include"); idx != -1 {
-
-			// TODO: Do this better
-			includeLength := len("!include ")
-
-			includedFile := line[idx+includeLength:]
-
-			preprocessedContents.Write([]byte(line[:idx]))
-
-			// Get the included file contents
-			includedContents, err :=
-				readFileContents(workingDirectory, includedFile)
-
-			if err != nil {
-				return nil,
-					fmt.Errorf("Error including file %s:\n    %s",
-						includedFile, err.Error())
-			}
-
-			// TODO: Check that you only insert .yaml, .raml, .txt and .md files
-			// In case of .raml or .yaml, remove the comments
-			// In case of other files, Base64 them first.
-
-			// TODO: Better, step by step checks .. though prolly it'll panic
-			// Write text files in the same indentation as the first line
-			internalScanner :=
-				bufio.NewScanner(bytes.NewBuffer(includedContents))
-
-			// Indent by this much
-			firstLine := true
-			indentationString := ""
-
-			// Go over each line, write it
-			for internalScanner.Scan() {
-				internalLine := internalScanner.Text()
-
-				preprocessedContents.WriteString(indentationString)
-				if firstLine {
-					indentationString = strings.Repeat(" ", idx)
-					firstLine = false
-				}
-
-				preprocessedContents.WriteString(internalLine)
-				preprocessedContents.WriteByte('\n')
-			}
+// includer returns a tag handler for the !include directive that reads
+// files from fsys, relative to the including document. RAML and YAML files
+// are included as structured data, anything else (JSON, XSD, Markdown and
+// so on) as a string. If record isn't nil it is called with the name of
+// each file included.
+func includer(fsys fs.FS, record func(name string)) yaml.TagHandler {
+	return func(tag, value, doc string) (string, []byte, bool, error) {
+		includedFile := path.Join(path.Dir(doc), strings.TrimSpace(value))
+		if record != nil {
+			record(includedFile)
+		}
 
-		} else {
+		// Get the included file contents
+		includedContents, err := readFileContents(fsys, includedFile)
+		if err != nil {
+			return "", nil, false,
+				fmt.Errorf("Error including file %s:\n    %s",
+					includedFile, err.Error())
+		}
 
-			// No, just a simple line.. write it
-			preprocessedContents.WriteString(line)
-			preprocessedContents.WriteByte('\n')
+		switch path.Ext(includedFile) {
+		case ".raml", ".yaml", ".yml":
+			return includedFile, includedContents, true, nil
 		}
-	}
 
-	// Any errors encountered?
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("Error reading YAML file: %s", err.Error())
+		return includedFile, includedContents, false, nil
 	}
-
-	// Return the preprocessed contents
-	return preprocessedContents.Bytes(), nil
 }
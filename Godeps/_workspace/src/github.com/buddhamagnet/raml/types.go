@@ -32,6 +32,12 @@ package raml
 
 // This file contains all of the RAML types.
 
+import (
+	"io/fs"
+
+	yaml "github.com/buddhamagnet/yaml"
+)
+
 // TODO: We don't support !include of non-text files. RAML supports including
 //       of many file types.
 
@@ -253,8 +259,44 @@ type Response struct {
 // A ResourceType/Trait/SecurityScheme choice contains the name of a
 // ResourceType/Trait/SecurityScheme as well as the parameters used to create
 // an instance of it.
-// Parameters MUST be of type string.
-type DefinitionParameters map[string]string
+// Parameters of resource types and traits MUST be of type string. Those of
+// security schemes MAY be sequences, such as the scopes of OAuth 2.0.
+type DefinitionParameters map[string]DefinitionParameter
+
+// values returns the parameters given as scalars, as template parameters
+// are.
+func (dp DefinitionParameters) values() map[string]string {
+	values := make(map[string]string, len(dp))
+	for name, p := range dp {
+		values[name] = p.Value
+	}
+	return values
+}
+
+// A DefinitionParameter holds a parameter's value, or its values if it
+// was given as a sequence.
+type DefinitionParameter struct {
+	Value string
+	List  []string
+}
+
+// Unmarshal a node which MIGHT be a scalar or a sequence of scalars
+func (dp *DefinitionParameter) UnmarshalYAML(unmarshaler func(interface{}) error) error {
+	if err := unmarshaler(&dp.Value); err == nil {
+		return nil
+	}
+	return unmarshaler(&dp.List)
+}
+
+// Values returns the values of a sequence, or the value of a scalar as a
+// list of one.
+func (dp DefinitionParameter) Values() []string {
+	if dp.List != nil || dp.Value == "" {
+		return dp.List
+	}
+	return []string{dp.Value}
+}
+
 type DefinitionChoice struct {
 	Name string
 
@@ -439,6 +481,10 @@ type ResourceType struct {
 	// Briefly describes what the resource type
 	Description string
 
+	// The resource type this one inherits from, if any. Its properties
+	// apply where this resource type doesn't set them.
+	Type *DefinitionChoice `yaml:"type"`
+
 	// As in Resource.
 	UriParameters map[string]NamedParameter `yaml:"uriParameters"`
 
@@ -449,12 +495,15 @@ type ResourceType struct {
 	// resource. A method MUST be one of the HTTP methods defined in the
 	// HTTP version 1.1 specification [RFC2616] and its extension,
 	// RFC5789 [RFC5789].
-	Get    *ResourceTypeMethod `yaml:"get"`
-	Head   *ResourceTypeMethod `yaml:"head"`
-	Post   *ResourceTypeMethod `yaml:"post"`
-	Put    *ResourceTypeMethod `yaml:"put"`
-	Delete *ResourceTypeMethod `yaml:"delete"`
-	Patch  *ResourceTypeMethod `yaml:"patch"`
+	Get     *ResourceTypeMethod `yaml:"get"`
+	Head    *ResourceTypeMethod `yaml:"head"`
+	Post    *ResourceTypeMethod `yaml:"post"`
+	Put     *ResourceTypeMethod `yaml:"put"`
+	Delete  *ResourceTypeMethod `yaml:"delete"`
+	Patch   *ResourceTypeMethod `yaml:"patch"`
+	Options *ResourceTypeMethod `yaml:"options"`
+	Trace   *ResourceTypeMethod `yaml:"trace"`
+	Connect *ResourceTypeMethod `yaml:"connect"`
 
 	// When defining resource types and traits, it can be useful to capture
 	// patterns that manifest several levels below the inheriting resource or
@@ -475,6 +524,9 @@ type ResourceType struct {
 	OptionalPut               *ResourceTypeMethod       `yaml:"put?"`
 	OptionalDelete            *ResourceTypeMethod       `yaml:"delete?"`
 	OptionalPatch             *ResourceTypeMethod       `yaml:"patch?"`
+	OptionalOptions           *ResourceTypeMethod       `yaml:"options?"`
+	OptionalTrace             *ResourceTypeMethod       `yaml:"trace?"`
+	OptionalConnect           *ResourceTypeMethod       `yaml:"connect?"`
 }
 
 // A trait-like structure to a security scheme mechanism so as to extend
@@ -512,7 +564,7 @@ type SecurityScheme struct {
 	// SHOULD describe the security schemes' required artifacts, such as
 	// headers, URI parameters, and so on.
 	// Including the security schemes' description completes an API's documentation.
-	DescribedBy SecuritySchemeMethod
+	DescribedBy SecuritySchemeMethod `yaml:"describedBy"`
 
 	// The settings attribute MAY be used to provide security schema-specific
 	// information. Depending on the value of the type parameter, its attributes
@@ -603,7 +655,7 @@ type Resource struct {
 	// TODO: Fill this during the post-processing phase
 
 	// A friendly name to the resource
-	DisplayName string
+	DisplayName string `yaml:"displayName"`
 
 	// Briefly describes the resource
 	Description string
@@ -665,12 +717,15 @@ type Resource struct {
 	// resource. A method MUST be one of the HTTP methods defined in the
 	// HTTP version 1.1 specification [RFC2616] and its extension,
 	// RFC5789 [RFC5789].
-	Get    *Method `yaml:"get"`
-	Head   *Method `yaml:"head"`
-	Post   *Method `yaml:"post"`
-	Put    *Method `yaml:"put"`
-	Delete *Method `yaml:"delete"`
-	Patch  *Method `yaml:"patch"`
+	Get     *Method `yaml:"get"`
+	Head    *Method `yaml:"head"`
+	Post    *Method `yaml:"post"`
+	Put     *Method `yaml:"put"`
+	Delete  *Method `yaml:"delete"`
+	Patch   *Method `yaml:"patch"`
+	Options *Method `yaml:"options"`
+	Trace   *Method `yaml:"trace"`
+	Connect *Method `yaml:"connect"`
 
 	// A resource defined as a child property of another resource is called a
 	// nested resource, and its property's key is its URI relative to its
@@ -679,7 +734,7 @@ type Resource struct {
 }
 
 func (r *Resource) Methods() []*Method {
-	methods := make([]*Method, 0, 6)
+	methods := make([]*Method, 0, 9)
 	if r.Get != nil {
 		methods = append(methods, r.Get)
 	}
@@ -698,6 +753,15 @@ func (r *Resource) Methods() []*Method {
 	if r.Delete != nil {
 		methods = append(methods, r.Delete)
 	}
+	if r.Options != nil {
+		methods = append(methods, r.Options)
+	}
+	if r.Trace != nil {
+		methods = append(methods, r.Trace)
+	}
+	if r.Connect != nil {
+		methods = append(methods, r.Connect)
+	}
 
 	return methods
 }
@@ -726,7 +790,7 @@ type APIDefinition struct {
 	// base URI parameters are available for replacement:
 	//
 	// version - The content of the version field.
-	BaseUri string
+	BaseUri string `yaml:"baseUri"`
 	// TODO: If a URI template variable in the base URI is not explicitly
 	// described in a baseUriParameters property, and is not specified in a
 	// resource-level baseUriParameters property, it MUST still be treated as
@@ -827,6 +891,80 @@ type APIDefinition struct {
 	// resource is called a nested resource, and its property's key is its
 	// URI relative to its parent resource's URI.
 	Resources map[string]Resource `yaml:",regexp:/.*"`
+
+	// Positions of the parsed nodes keyed by path, and the positions of
+	// keys not recognised by the parser. Used by the validator.
+	marks   map[string]yaml.Mark
+	unknown []yaml.Mark
+
+	// The document the definition was parsed from, for Resolved, and
+	// the files it included.
+	source   *source
+	included []string
+}
+
+type source struct {
+	fsys     fs.FS
+	name     string
+	contents []byte
+}
+
+// Resolved returns the RAML document the definition was parsed from with
+// every !include replaced by the content it refers to. Comments are lost.
+// It returns nil if the definition wasn't parsed by this package.
+func (r *APIDefinition) Resolved() ([]byte, error) {
+	if r.source == nil {
+		return nil, nil
+	}
+
+	var doc yaml.MapSlice
+	decoder := &yaml.Decoder{Tags: map[string]yaml.TagHandler{"!include": includer(r.source.fsys, nil)}}
+	if err := decoder.Unmarshal(r.source.name, r.source.contents, &doc); err != nil {
+		return nil, err
+	}
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(r.RAMLVersion+"\n"), out...), nil
+}
+
+// Files returns the name of the file the definition was parsed from,
+// followed by every file it included, directly or not, in the order they
+// were first included. Names are those used to open the files, so files
+// parsed with ParseFile are named relative to the working directory.
+// Definitions parsed from memory have no name of their own.
+func (r *APIDefinition) Files() []string {
+	var files []string
+	seen := make(map[string]bool)
+	if r.source != nil && r.source.name != "" {
+		files = append(files, r.source.name)
+		seen[r.source.name] = true
+	}
+	for _, name := range r.included {
+		if !seen[name] {
+			seen[name] = true
+			files = append(files, name)
+		}
+	}
+	return files
+}
+
+// NewProblem returns a problem with the node at path, for example
+// ["/users", "get", "responses", "200"]. If the definition was parsed by
+// this package the problem is positioned at the node, or its closest
+// parsed ancestor.
+func (r *APIDefinition) NewProblem(path []string, message string) *Problem {
+	var mark yaml.Mark
+	for i := len(path); i > 0; i-- {
+		if m, ok := r.marks[markKey(path[:i])]; ok {
+			mark = m
+			break
+		}
+	}
+	mark.Path = path
+	return problemAt(mark, message)
 }
 
 // This function receives a path, splits it and traverses the resource
@@ -29,10 +29,353 @@ package raml
 
 // This file contains all of the RAML schema validator related code.
 
-// TODO: Inspirations:
+// Inspirations:
 // 		https://www.npmjs.com/package/raml-validate
 //		https://github.com/go-validator/validator
 //		https://github.com/asaskevich/govalidator
 
 // And of course:
 // 		https://github.com/raml-org/raml-java-parser/tree/master/src/main/java/org/raml/parser/rule
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The primitive types a named parameter may have. An empty type means
+// string.
+var parameterTypes = map[string]bool{
+	"":        true,
+	"string":  true,
+	"number":  true,
+	"integer": true,
+	"date":    true,
+	"boolean": true,
+	"file":    true,
+}
+
+// Matches URI template variables, e.g. {id}
+var uriParameter = regexp.MustCompile(`{([^}]+)}`)
+
+// Validate checks a RAML API definition for semantic problems that parsing
+// doesn't catch: unknown keys, invalid parameter types, URI parameters
+// missing from their path, references to undefined traits, resource types,
+// schemas and security schemes, invalid HTTP codes, and examples that don't
+// match their schemas or parameters. All problems found are returned in a
+// *RamlError, located in the original files when the definition was
+// parsed by this package and sorted by position.
+func Validate(api *APIDefinition) error {
+	v := &validator{
+		api:             api,
+		traits:          make(map[string]bool),
+		resourceTypes:   make(map[string]bool),
+		schemas:         make(map[string]string),
+		securitySchemes: make(map[string]bool),
+		err:             &RamlError{validation: true},
+	}
+	v.validate()
+
+	if len(v.err.Problems) == 0 {
+		return nil
+	}
+	v.err.sort()
+	return v.err
+}
+
+type validator struct {
+	api             *APIDefinition
+	traits          map[string]bool
+	resourceTypes   map[string]bool
+	schemas         map[string]string
+	securitySchemes map[string]bool
+	err             *RamlError
+}
+
+// markKey returns the key of a path in the positions map.
+func markKey(path []string) string {
+	return strings.Join(path, "\x00")
+}
+
+// join returns a copy of path with elems appended, so paths can be built
+// up without sharing backing arrays.
+func join(path []string, elems ...string) []string {
+	return append(append(make([]string, 0, len(path)+len(elems)), path...), elems...)
+}
+
+// report adds a problem at path.
+func (v *validator) report(path []string, format string, args ...interface{}) {
+	v.err.add(v.api.NewProblem(path, fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) validate() {
+	for _, mark := range v.api.unknown {
+		v.err.add(problemAt(mark,
+			fmt.Sprintf("unknown key %q", mark.Path[len(mark.Path)-1])))
+	}
+
+	// Collect the names that can be referred to
+	for _, traits := range v.api.Traits {
+		for name := range traits {
+			v.traits[name] = true
+		}
+	}
+	for _, types := range v.api.ResourceTypes {
+		for name := range types {
+			v.resourceTypes[name] = true
+		}
+	}
+	for _, schemas := range v.api.Schemas {
+		for name, schema := range schemas {
+			v.schemas[name] = schema
+		}
+	}
+	for _, schemes := range v.api.SecuritySchemes {
+		for name := range schemes {
+			v.securitySchemes[name] = true
+		}
+	}
+
+	baseURIParams := uriParameterNames(v.api.BaseUri)
+	v.params([]string{"baseUriParameters"}, v.api.BaseUriParameters, baseURIParams)
+	v.securedBy(nil, v.api.SecuredBy)
+
+	for i, traits := range v.api.Traits {
+		for name, trait := range traits {
+			path := []string{"traits", strconv.Itoa(i), name}
+			v.params(join(path, "headers"), headerParams(trait.Headers), nil)
+			v.params(join(path, "queryParameters"), trait.QueryParameters, nil)
+			v.bodies(join(path, "body"), trait.Bodies)
+			v.responses(join(path, "responses"), trait.Responses)
+		}
+	}
+
+	for i, types := range v.api.ResourceTypes {
+		for name, resourceType := range types {
+			if resourceType.Type != nil && !v.resourceTypes[resourceType.Type.Name] {
+				v.report([]string{"resourceTypes", strconv.Itoa(i), name, "type"},
+					"undefined resource type %q", resourceType.Type.Name)
+			}
+		}
+	}
+
+	for uri, resource := range v.api.Resources {
+		resource := resource
+		v.resource([]string{uri}, uri, &resource, baseURIParams)
+	}
+}
+
+func (v *validator) resource(path []string, uri string, r *Resource, baseURIParams map[string]bool) {
+	if r.Type != nil && !v.resourceTypes[r.Type.Name] {
+		v.report(join(path, "type"), "undefined resource type %q", r.Type.Name)
+	}
+	v.is(path, r.Is)
+	v.securedBy(path, r.SecuredBy)
+	v.params(join(path, "uriParameters"), r.UriParameters, uriParameterNames(uri))
+	v.params(join(path, "baseUriParameters"), r.BaseUriParameters, baseURIParams)
+
+	for _, m := range r.Methods() {
+		mpath := join(path, strings.ToLower(m.Name))
+		v.is(mpath, m.Is)
+		v.securedBy(mpath, m.SecuredBy)
+		v.params(join(mpath, "headers"), headerParams(m.Headers), nil)
+		v.params(join(mpath, "queryParameters"), m.QueryParameters, nil)
+		v.bodies(join(mpath, "body"), m.Bodies)
+		v.responses(join(mpath, "responses"), m.Responses)
+	}
+
+	for nested, child := range r.Nested {
+		v.resource(join(path, nested), uri+nested, child, baseURIParams)
+	}
+}
+
+// is checks that applied traits are defined.
+func (v *validator) is(path []string, is []DefinitionChoice) {
+	for i, trait := range is {
+		if !v.traits[trait.Name] {
+			v.report(join(path, "is", strconv.Itoa(i)), "undefined trait %q", trait.Name)
+		}
+	}
+}
+
+// securedBy checks that security schemes are defined. null means the
+// method may be used without security.
+func (v *validator) securedBy(path []string, securedBy []DefinitionChoice) {
+	for i, scheme := range securedBy {
+		if scheme.Name != "" && scheme.Name != "null" && !v.securitySchemes[scheme.Name] {
+			v.report(join(path, "securedBy", strconv.Itoa(i)), "undefined security scheme %q", scheme.Name)
+		}
+	}
+}
+
+// params checks a set of named parameters. If allowed is not nil, the
+// parameter names must be in it.
+func (v *validator) params(path []string, params map[string]NamedParameter, allowed map[string]bool) {
+	for name, param := range params {
+		ppath := join(path, name)
+		if allowed != nil && !allowed[name] {
+			v.report(ppath, "parameter %q does not appear in the URI", name)
+		}
+		v.param(ppath, param)
+	}
+}
+
+func (v *validator) param(path []string, param NamedParameter) {
+	if !parameterTypes[param.Type] {
+		v.report(join(path, "type"), "invalid parameter type %q", param.Type)
+		return
+	}
+	if param.Pattern != nil {
+		if _, err := regexp.Compile(*param.Pattern); err != nil {
+			v.report(join(path, "pattern"), "invalid pattern: %s", err.Error())
+			return
+		}
+	}
+	if param.Example != "" {
+		if err := CheckParameter(param, param.Example); err != nil {
+			v.report(join(path, "example"), "example %s", err.Error())
+		}
+	}
+	if param.Default != nil {
+		if err := CheckParameter(param, fmt.Sprint(param.Default)); err != nil {
+			v.report(join(path, "default"), "default %s", err.Error())
+		}
+	}
+}
+
+func (v *validator) responses(path []string, responses map[HTTPCode]Response) {
+	for code, response := range responses {
+		rpath := join(path, strconv.Itoa(int(code)))
+		if code < 100 || code > 599 {
+			v.report(rpath, "invalid HTTP code %d", code)
+		}
+		v.params(join(rpath, "headers"), headerParams(response.Headers), nil)
+		v.bodies(join(rpath, "body"), response.Bodies)
+	}
+}
+
+func (v *validator) bodies(path []string, bodies Bodies) {
+	for mediaType, body := range bodies.ForMIMEType {
+		bpath := join(path, mediaType)
+		v.params(join(bpath, "formParameters"), body.FormParameters, nil)
+
+		schema := body.Schema
+		if named, ok := v.schemas[schema]; ok {
+			schema = named
+		} else if schema != "" && !strings.ContainsAny(schema, "{<") {
+			v.report(join(bpath, "schema"), "undefined schema %q", schema)
+			continue
+		}
+
+		if body.Example == "" || !strings.Contains(mediaType, "json") {
+			continue
+		}
+		var example interface{}
+		if err := json.Unmarshal([]byte(body.Example), &example); err != nil {
+			v.report(join(bpath, "example"), "example is not valid JSON: %s", err.Error())
+			continue
+		}
+		if !strings.HasPrefix(strings.TrimSpace(schema), "{") {
+			continue
+		}
+		var jsonSchema map[string]interface{}
+		if err := json.Unmarshal([]byte(schema), &jsonSchema); err != nil {
+			v.report(join(bpath, "schema"), "schema is not valid JSON: %s", err.Error())
+			continue
+		}
+		for _, mismatch := range validateJSON(jsonSchema, example, "example") {
+			v.report(join(bpath, "example"), "%s", mismatch)
+		}
+	}
+}
+
+// CheckParameter checks that a value satisfies the constraints of a named
+// parameter: its type, enum, pattern, length and range.
+func CheckParameter(param NamedParameter, value string) error {
+	switch param.Type {
+	case "integer":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+	case "boolean":
+		if value != "true" && value != "false" {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+	case "date":
+		if _, err := http.ParseTime(value); err != nil {
+			return fmt.Errorf("%q is not an RFC2616 date", value)
+		}
+	}
+
+	if len(param.Enum) > 0 {
+		found := false
+		for _, e := range param.Enum {
+			if fmt.Sprint(e) == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%q is not one of %v", value, param.Enum)
+		}
+	}
+	if param.Pattern != nil {
+		// Patterns match anywhere in the value, as JavaScript's RegExp.test
+		// does, unless they're anchored with ^ and $
+		re, err := regexp.Compile(*param.Pattern)
+		if err != nil {
+			return fmt.Errorf("has an invalid pattern: %s", err.Error())
+		}
+		if !re.MatchString(value) {
+			return fmt.Errorf("%q does not match pattern %s", value, *param.Pattern)
+		}
+	}
+	length := utf8.RuneCountInString(value)
+	if param.MinLength != nil && length < *param.MinLength {
+		return fmt.Errorf("%q is shorter than %d", value, *param.MinLength)
+	}
+	if param.MaxLength != nil && length > *param.MaxLength {
+		return fmt.Errorf("%q is longer than %d", value, *param.MaxLength)
+	}
+	if param.Minimum != nil || param.Maximum != nil {
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			n = math.NaN()
+		}
+		if param.Minimum != nil && !(n >= *param.Minimum) {
+			return fmt.Errorf("%q is less than %v", value, *param.Minimum)
+		}
+		if param.Maximum != nil && !(n <= *param.Maximum) {
+			return fmt.Errorf("%q is greater than %v", value, *param.Maximum)
+		}
+	}
+
+	return nil
+}
+
+// uriParameterNames returns the names of the template variables in a URI.
+func uriParameterNames(uri string) map[string]bool {
+	names := make(map[string]bool)
+	for _, m := range uriParameter.FindAllStringSubmatch(uri, -1) {
+		names[m[1]] = true
+	}
+	return names
+}
+
+// headerParams converts headers to named parameters.
+func headerParams(headers map[HTTPHeader]Header) map[string]NamedParameter {
+	params := make(map[string]NamedParameter, len(headers))
+	for name, header := range headers {
+		params[string(name)] = NamedParameter(header)
+	}
+	return params
+}
@@ -1,6 +1,7 @@
 package yaml
 
 import (
+	"bytes"
 	"encoding"
 	"encoding/base64"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 	"reflect"
 	"strconv"
 	"time"
+	"unicode/utf8"
 )
 
 const (
@@ -21,6 +23,7 @@ const (
 type node struct {
 	kind         int
 	line, column int
+	doc          string
 	tag          string
 	value        string
 	implicit     bool
@@ -77,15 +80,17 @@ func (p *parser) skip() {
 }
 
 func (p *parser) fail() {
-	var where string
-	var line int
-	if p.parser.problem_mark.line != 0 {
-		line = p.parser.problem_mark.line
-	} else if p.parser.context_mark.line != 0 {
-		line = p.parser.context_mark.line
-	}
-	if line != 0 {
-		where = "line " + strconv.Itoa(line) + ": "
+	// Marks are 0-based; take the line and column from the same one
+	mark := p.parser.problem_mark
+	if mark == (yaml_mark_t{}) {
+		mark = p.parser.context_mark
+	}
+	// At the end of input without a final newline libyaml moves the mark
+	// on to a line that isn't there, so point after the last character
+	input := p.parser.input
+	if mark.line > 0 && mark.column == 0 && mark.index == utf8.RuneCount(input) && input[len(input)-1] != '\n' {
+		mark.line--
+		mark.column = utf8.RuneCount(input[bytes.LastIndexByte(input, '\n')+1:])
 	}
 	var msg string
 	if len(p.parser.problem) > 0 {
@@ -93,7 +98,10 @@ func (p *parser) fail() {
 	} else {
 		msg = "unknown problem parsing YAML content"
 	}
-	failf("%s%s", where, msg)
+	fail(&DocumentError{
+		Mark:    Mark{Line: mark.line + 1, Column: mark.column + 1},
+		Message: msg,
+	})
 }
 
 func (p *parser) anchor(n *node, anchor []byte) {
@@ -191,6 +199,9 @@ type decoder struct {
 	aliases map[string]bool
 	mapType reflect.Type
 	terrors []string
+	tmarks  []Mark
+	path    []string
+	visit   func(mark Mark, unknown bool)
 }
 
 var (
@@ -223,6 +234,30 @@ func (d *decoder) terror(n *node, tag string, out reflect.Value) {
 	}
 
 	d.terrors = append(d.terrors, fmt.Sprintf("line %d: cannot unmarshal %s%s into %s", n.line+1, shortTag(tag), value, out.Type()))
+	d.tmarks = append(d.tmarks, d.mark(n))
+}
+
+// mark returns the position of n, which is being decoded at the
+// decoder's current path.
+func (d *decoder) mark(n *node) Mark {
+	return Mark{
+		Doc:    n.doc,
+		Line:   n.line + 1,
+		Column: n.column + 1,
+		Path:   append([]string(nil), d.path...),
+	}
+}
+
+// unmarshalAt unmarshals n into out with the key of the node appended to
+// the decoder's current path. The key node locates n for the visitor.
+func (d *decoder) unmarshalAt(key string, keyNode, n *node, out reflect.Value) (good bool) {
+	d.path = append(d.path, key)
+	if d.visit != nil {
+		d.visit(d.mark(keyNode), false)
+	}
+	good = d.unmarshal(n, out)
+	d.path = d.path[:len(d.path)-1]
+	return good
 }
 
 // Use the Unmarshaler.UnmarshalYAML for types that are Unmarshalers, performing
@@ -245,12 +280,14 @@ func (d *decoder) callUnmarshaler(n *node, u Unmarshaler) (good bool) {
 
 			// Get new decoder errors
 			issues := d.terrors[terrlen:]
+			marks := d.tmarks[terrlen:]
 
 			// Remove them from the decoder errors list
 			d.terrors = d.terrors[:terrlen]
+			d.tmarks = d.tmarks[:terrlen]
 
 			// And return just the specific decoder errors to the calling type
-			return &TypeError{issues}
+			return &TypeError{issues, marks}
 		}
 
 		return nil
@@ -260,6 +297,15 @@ func (d *decoder) callUnmarshaler(n *node, u Unmarshaler) (good bool) {
 	// calling object to return the errors it received
 	if e, ok := err.(*TypeError); ok {
 		d.terrors = append(d.terrors, e.Errors...)
+		// Unmarshalers may return errors without marks, so pad them to
+		// keep the two lists in step
+		for i := range e.Errors {
+			var mark Mark
+			if i < len(e.Marks) {
+				mark = e.Marks[i]
+			}
+			d.tmarks = append(d.tmarks, mark)
+		}
 		return false
 	}
 
@@ -366,8 +412,12 @@ func (d *decoder) unmarshal(n *node, out reflect.Value) (good bool) {
 
 func (d *decoder) document(n *node, out reflect.Value) (good bool) {
 	if len(n.children) == 1 {
+		// Documents may be nested when they are included by a tag
+		// handler, so restore the outer document's anchors afterwards.
+		doc := d.doc
 		d.doc = n
 		d.unmarshal(n.children[0], out)
+		d.doc = doc
 		return true
 	}
 	return false
@@ -597,7 +647,7 @@ func (d *decoder) sequence(n *node, out reflect.Value) (good bool) {
 	l := len(n.children)
 	for i := 0; i < l; i++ {
 		e := reflect.New(et).Elem()
-		if ok := d.unmarshal(n.children[i], e); ok {
+		if ok := d.unmarshalAt(strconv.Itoa(i), n.children[i], n.children[i], e); ok {
 			out.Set(reflect.Append(out, e))
 		}
 	}
@@ -690,7 +740,7 @@ func (d *decoder) mapping(n *node, out reflect.Value) (good bool) {
 			e := reflect.New(et).Elem()
 
 			// Unmarshal into the element value
-			if d.unmarshal(n.children[i+1], e) {
+			if d.unmarshalAt(n.children[i].value, n.children[i], n.children[i+1], e) {
 
 				// Set it into the map!
 				out.SetMapIndex(k, e)
@@ -725,7 +775,7 @@ func (d *decoder) mappingSlice(n *node, out reflect.Value) (good bool) {
 		k := reflect.ValueOf(&item.Key).Elem()
 		if d.unmarshal(n.children[i], k) {
 			v := reflect.ValueOf(&item.Value).Elem()
-			if d.unmarshal(n.children[i+1], v) {
+			if d.unmarshalAt(n.children[i].value, n.children[i], n.children[i+1], v) {
 				slice = append(slice, item)
 			}
 		}
@@ -777,12 +827,14 @@ func (d *decoder) mappingStruct(n *node, out reflect.Value) (good bool) {
 				field = out.FieldByIndex(info.Inline)
 			}
 
-			d.unmarshal(n.children[i+1], field)
+			d.unmarshalAt(name.String(), ni, n.children[i+1], field)
 		} else {
 			// Otherwise, we try to see if the YAML key matches any regular
 			// expression
+			matched := false
 			for _, info := range sinfo.RegexpFieldsList {
 				if info.Regexp.MatchString(name.String()) {
+					matched = true
 
 					// Get the field. It must be a map or a slice
 					var field reflect.Value = out.Field(info.Num)
@@ -803,7 +855,7 @@ func (d *decoder) mappingStruct(n *node, out reflect.Value) (good bool) {
 						e := reflect.New(field.Type().Elem()).Elem()
 
 						// Unmarshal into the element value
-						if d.unmarshal(n.children[i+1], e) {
+						if d.unmarshalAt(name.String(), ni, n.children[i+1], e) {
 
 							// Set it into the map!
 							field.SetMapIndex(name, e)
@@ -826,7 +878,7 @@ func (d *decoder) mappingStruct(n *node, out reflect.Value) (good bool) {
 						e := reflect.New(field.Type().Elem()).Elem()
 
 						// Unmarshal into the element value
-						if d.unmarshal(n.children[i+1], e) {
+						if d.unmarshalAt(name.String(), ni, n.children[i+1], e) {
 
 							// Append it to the slice
 							newSlice := reflect.Append(field, e)
@@ -841,6 +893,13 @@ func (d *decoder) mappingStruct(n *node, out reflect.Value) (good bool) {
 
 				}
 			}
+
+			// Neither matched, let the visitor know about the stray key
+			if d.visit != nil && !matched {
+				d.path = append(d.path, name.String())
+				d.visit(d.mark(ni), true)
+				d.path = d.path[:len(d.path)-1]
+			}
 		}
 	}
 	return true
@@ -0,0 +1,122 @@
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// A TagHandler expands scalar values carrying a custom tag, such as RAML's
+// !include. It is given the tag, the scalar value and the name of the
+// document the value was found in. It returns the name and content of the
+// replacement: if isDoc is true the content is parsed as a YAML document in
+// place of the scalar, otherwise it is used as a plain string.
+type TagHandler func(tag, value, doc string) (name string, content []byte, isDoc bool, err error)
+
+// A Decoder unmarshals YAML documents, expanding custom tags with the
+// registered handlers.
+type Decoder struct {
+	// Tags maps custom tags, such as "!include", to their handlers.
+	Tags map[string]TagHandler
+
+	// Visit, if not nil, is called with the position of each mapping
+	// value and sequence item as it is decoded. Mapping keys that don't
+	// match any field of the struct being decoded into are reported with
+	// unknown set to true.
+	Visit func(mark Mark, unknown bool)
+}
+
+// Unmarshal decodes the document called name into out. It behaves like the
+// package level Unmarshal function, except that custom tags are expanded
+// before decoding. Documents pulled in by a handler may themselves contain
+// custom tags; a document that ends up including itself is an error.
+func (dec *Decoder) Unmarshal(name string, in []byte, out interface{}) (err error) {
+	defer handleErr(&err)
+	d := newDecoder()
+	d.visit = dec.Visit
+	node := parseDocument(name, in)
+	if node != nil {
+		dec.expand(node, name, []string{name})
+		v := reflect.ValueOf(out)
+		if v.Kind() == reflect.Ptr && !v.IsNil() {
+			v = v.Elem()
+		}
+		d.unmarshal(node, v)
+	}
+	if d.terrors != nil && len(d.terrors) > 0 {
+		return &TypeError{d.terrors, d.tmarks}
+	}
+	return nil
+}
+
+// expand walks the node tree of the document called doc, replacing scalars
+// with custom tags by the content returned from their handlers. The names
+// of the documents being expanded are held in stack to detect cycles.
+func (dec *Decoder) expand(n *node, doc string, stack []string) {
+	n.doc = doc
+	if n.kind != scalarNode {
+		for _, child := range n.children {
+			dec.expand(child, doc, stack)
+		}
+		return
+	}
+
+	handler, ok := dec.Tags[n.tag]
+	if !ok {
+		return
+	}
+
+	name, content, isDoc, err := handler(n.tag, n.value, doc)
+	if err != nil {
+		failAt(n, err.Error())
+	}
+
+	if !isDoc {
+		n.tag = yaml_STR_TAG
+		n.value = string(content)
+		n.implicit = false
+		return
+	}
+
+	for _, s := range stack {
+		if s == name {
+			failAt(n, fmt.Sprintf("cyclic %s of %s", n.tag, name))
+		}
+	}
+
+	sub := parseDocument(name, content)
+	if sub == nil {
+		// An empty document is a null value.
+		n.tag = yaml_NULL_TAG
+		n.value = ""
+		return
+	}
+	dec.expand(sub, name, append(stack[:len(stack):len(stack)], name))
+	*n = *sub
+}
+
+// parseDocument parses the content of the document called name into a node
+// tree, reporting syntax errors against name.
+func parseDocument(name string, content []byte) (n *node) {
+	var err error
+	func() {
+		defer handleErr(&err)
+		p := newParser(content)
+		defer p.destroy()
+		n = p.parse()
+	}()
+	if e, ok := err.(*DocumentError); ok {
+		e.Mark.Doc = name
+	}
+	if err != nil {
+		fail(err)
+	}
+	return n
+}
+
+// failAt fails with a DocumentError at the position of n.
+func failAt(n *node, msg string) {
+	fail(&DocumentError{
+		Mark:    Mark{Doc: n.doc, Line: n.line + 1, Column: n.column + 1},
+		Message: msg,
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -161,7 +162,7 @@ func Unmarshal(in []byte, out interface{}) (err error) {
 		d.unmarshal(node, v)
 	}
 	if d.terrors != nil && len(d.terrors) > 0 {
-		return &TypeError{d.terrors}
+		return &TypeError{d.terrors, d.tmarks}
 	}
 	return nil
 }
@@ -246,6 +247,41 @@ func failf(format string, args ...interface{}) {
 // unmarshaled partially.
 type TypeError struct {
 	Errors []string
+
+	// Marks holds the position of each of the Errors.
+	Marks []Mark
+}
+
+// A Mark locates a node within a YAML document.
+type Mark struct {
+	// Doc is the name of the document, if it has one. It is set for
+	// documents decoded with a Decoder.
+	Doc string
+
+	// Line and Column are the 1-based position of the node.
+	Line, Column int
+
+	// Path holds the mapping keys and sequence indexes leading from the
+	// root of the document to the node.
+	Path []string
+}
+
+// A DocumentError is returned when a document can't be decoded at all,
+// for instance because it is not well-formed YAML.
+type DocumentError struct {
+	Mark    Mark
+	Message string
+}
+
+func (e *DocumentError) Error() string {
+	where := ""
+	if e.Mark.Doc != "" {
+		where = e.Mark.Doc + ": "
+	}
+	if e.Mark.Line != 0 {
+		where += "line " + strconv.Itoa(e.Mark.Line) + ": "
+	}
+	return "yaml: " + where + e.Message
 }
 
 func (e *TypeError) Error() string {
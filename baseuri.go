@@ -0,0 +1,78 @@
+package ramlapi
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/buddhamagnet/raml"
+)
+
+var placeholder = regexp.MustCompile(`{([^}]+)}`)
+
+// WithBaseURI prefixes every endpoint path with the path of the API's
+// baseUri. Placeholders such as {version} are resolved from values,
+// then the API version, then the default or single enum value of the
+// matching baseUriParameter. Unresolved placeholders are left in the
+// path for the router to match. Handlers are named as they would be
+// without the prefix.
+func WithBaseURI(values map[string]string) Option {
+	return func(o *options) {
+		o.baseURI = true
+		o.baseURIValues = values
+	}
+}
+
+// baseURIParams merges the API's base URI parameters with those
+// overridden by a resource.
+func baseURIParams(api *raml.APIDefinition, resource *raml.Resource) map[string]raml.NamedParameter {
+	params := make(map[string]raml.NamedParameter)
+	for name, param := range api.BaseUriParameters {
+		params[name] = param
+	}
+	for name, param := range resource.BaseUriParameters {
+		params[name] = param
+	}
+	return params
+}
+
+// basePath returns the resolved path component of the API's baseUri.
+func basePath(api *raml.APIDefinition, params map[string]raml.NamedParameter, values map[string]string) string {
+	path := api.BaseUri
+	if i := strings.Index(path, "://"); i != -1 {
+		path = path[i+3:]
+	}
+	i := strings.Index(path, "/")
+	if i == -1 {
+		return ""
+	}
+	path = placeholder.ReplaceAllStringFunc(path[i:], func(p string) string {
+		if v, ok := resolveBaseURIParam(p[1:len(p)-1], api, params, values); ok {
+			return v
+		}
+		return p
+	})
+
+	return strings.TrimSuffix(path, "/")
+}
+
+func resolveBaseURIParam(name string, api *raml.APIDefinition, params map[string]raml.NamedParameter, values map[string]string) (string, bool) {
+	if v, ok := values[name]; ok {
+		return v, true
+	}
+	if name == "version" && api.Version != "" {
+		return api.Version, true
+	}
+	param, ok := params[name]
+	if !ok {
+		return "", false
+	}
+	if param.Default != nil {
+		return fmt.Sprint(param.Default), true
+	}
+	if len(param.Enum) == 1 {
+		return fmt.Sprint(param.Enum[0]), true
+	}
+
+	return "", false
+}
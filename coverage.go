@@ -0,0 +1,275 @@
+package ramlapi
+
+import (
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Coverage records which endpoints, response codes and parameters are
+// exercised by requests, from a test suite or real traffic, so they can
+// be compared with what the spec declares. It is safe for concurrent use.
+type Coverage struct {
+	mu        sync.Mutex
+	endpoints []*Endpoint
+	hits      map[*Endpoint]*coverageHits
+}
+
+type coverageHits struct {
+	requests int
+	codes    map[int]int
+	params   map[string]int
+}
+
+// NewCoverage returns an empty Coverage.
+func NewCoverage() *Coverage {
+	return &Coverage{hits: make(map[*Endpoint]*coverageHits)}
+}
+
+// Record wraps a handler to count the requests to an endpoint, the status
+// codes of its responses and the query parameters and headers it is sent.
+// Endpoints are reported from the time they are wrapped, so those never
+// called show as uncovered.
+func (c *Coverage) Record(ep *Endpoint, next http.Handler) http.Handler {
+	c.mu.Lock()
+	if _, ok := c.hits[ep]; !ok {
+		c.endpoints = append(c.endpoints, ep)
+		c.hits[ep] = &coverageHits{codes: make(map[int]int), params: make(map[string]int)}
+	}
+	c.mu.Unlock()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		hits := c.hits[ep]
+		hits.requests++
+		hits.codes[sw.status]++
+		query := r.URL.Query()
+		for _, p := range ep.QueryParameters {
+			if _, ok := query[p.Key]; ok {
+				hits.params["query "+p.Key]++
+			}
+		}
+		for _, p := range ep.Headers {
+			if len(headerValues(r.Header, p.Key)) > 0 {
+				hits.params["header "+p.Key]++
+			}
+		}
+	})
+}
+
+// statusWriter remembers the status code of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	wrote  bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wrote {
+		w.status, w.wrote = status, true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wrote = true
+	return w.ResponseWriter.Write(b)
+}
+
+// CoverageReport is the coverage of an API's endpoints.
+type CoverageReport struct {
+	Endpoints []*EndpointCoverage `json:"endpoints"`
+
+	// Covered and Total count the endpoints, declared responses and
+	// parameters exercised, and declared.
+	Covered int `json:"covered"`
+	Total   int `json:"total"`
+}
+
+// EndpointCoverage is the coverage of an endpoint.
+type EndpointCoverage struct {
+	Verb       string              `json:"verb"`
+	Path       string              `json:"path"`
+	Handler    string              `json:"handler"`
+	Requests   int                 `json:"requests"`
+	Responses  []*ResponseCoverage `json:"responses,omitempty"`
+	Parameters []*ParamCoverage    `json:"parameters,omitempty"`
+}
+
+// ResponseCoverage counts the responses with a status code. Codes that
+// were sent but not declared are included, with Declared false.
+type ResponseCoverage struct {
+	Code     int  `json:"code"`
+	Declared bool `json:"declared"`
+	Count    int  `json:"count"`
+}
+
+// ParamCoverage counts the requests sending a query parameter or header,
+// named like "query page" or "header X-Tenant".
+type ParamCoverage struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Percent returns the share of the endpoints, declared responses and
+// parameters that were exercised.
+func (r *CoverageReport) Percent() float64 {
+	if r.Total == 0 {
+		return 100
+	}
+	return 100 * float64(r.Covered) / float64(r.Total)
+}
+
+// Report returns the coverage recorded so far.
+func (c *Coverage) Report() *CoverageReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := &CoverageReport{}
+	count := func(n int) {
+		report.Total++
+		if n > 0 {
+			report.Covered++
+		}
+	}
+	for _, ep := range c.endpoints {
+		hits := c.hits[ep]
+		ec := &EndpointCoverage{Verb: ep.Verb, Path: ep.Path, Handler: ep.Handler, Requests: hits.requests}
+		count(hits.requests)
+
+		codes := make(map[int]bool)
+		if ep.Method != nil {
+			for code := range ep.Method.Responses {
+				codes[int(code)] = true
+			}
+		}
+		for code := range hits.codes {
+			if _, ok := codes[code]; !ok {
+				codes[code] = false
+			}
+		}
+		for _, code := range sortedInts(codes) {
+			rc := &ResponseCoverage{Code: code, Declared: codes[code], Count: hits.codes[code]}
+			if rc.Declared {
+				count(rc.Count)
+			}
+			ec.Responses = append(ec.Responses, rc)
+		}
+
+		for _, p := range ep.QueryParameters {
+			ec.Parameters = append(ec.Parameters, &ParamCoverage{"query " + p.Key, hits.params["query "+p.Key]})
+		}
+		for _, p := range ep.Headers {
+			ec.Parameters = append(ec.Parameters, &ParamCoverage{"header " + p.Key, hits.params["header "+p.Key]})
+		}
+		for _, p := range ec.Parameters {
+			count(p.Count)
+		}
+		report.Endpoints = append(report.Endpoints, ec)
+	}
+	sort.SliceStable(report.Endpoints, func(i, j int) bool {
+		a, b := report.Endpoints[i], report.Endpoints[j]
+		return a.Path < b.Path || (a.Path == b.Path && a.Verb < b.Verb)
+	})
+	return report
+}
+
+func sortedInts(m map[int]bool) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// WriteText writes the report as plain text, marking what was never
+// exercised.
+func (r *CoverageReport) WriteText(w io.Writer) error {
+	ew := &errWriter{w: w}
+	ew.printf("coverage: %d/%d (%.1f%%)\n", r.Covered, r.Total, r.Percent())
+	for _, ep := range r.Endpoints {
+		ew.printf("\n%s %s %s: %d requests%s\n", ep.Verb, ep.Path, ep.Handler, ep.Requests, missed(ep.Requests, true))
+		for _, rc := range ep.Responses {
+			ew.printf("  response %d: %d%s\n", rc.Code, rc.Count, missed(rc.Count, rc.Declared))
+		}
+		for _, p := range ep.Parameters {
+			ew.printf("  %s: %d%s\n", p.Name, p.Count, missed(p.Count, true))
+		}
+	}
+	return ew.err
+}
+
+func missed(n int, declared bool) string {
+	switch {
+	case !declared:
+		return " (undeclared)"
+	case n == 0:
+		return " (not covered)"
+	}
+	return ""
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *CoverageReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteHTML writes the report as a standalone HTML page.
+func (r *CoverageReport) WriteHTML(w io.Writer) error {
+	return coverageTemplate.Execute(w, r)
+}
+
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	if ew.err == nil {
+		_, ew.err = fmt.Fprintf(ew.w, format, args...)
+	}
+}
+
+var coverageTemplate = htmltemplate.Must(htmltemplate.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>API coverage</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+.missed { background: #fdd; }
+.undeclared { background: #ffd; }
+</style>
+</head>
+<body>
+<h1>API coverage</h1>
+<p>{{.Covered}} of {{.Total}} covered ({{printf "%.1f" .Percent}}%)</p>
+{{range .Endpoints}}
+<h2{{if not .Requests}} class="missed"{{end}}>{{.Verb}} {{.Path}}</h2>
+<p>{{.Handler}}: {{.Requests}} requests</p>
+<table>
+<tr><th>Response or parameter</th><th>Count</th></tr>
+{{- range .Responses}}
+<tr{{if not .Declared}} class="undeclared"{{else if not .Count}} class="missed"{{end}}><td>{{.Code}}{{if not .Declared}} (undeclared){{end}}</td><td>{{.Count}}</td></tr>
+{{- end}}
+{{- range .Parameters}}
+<tr{{if not .Count}} class="missed"{{end}}><td>{{.Name}}</td><td>{{.Count}}</td></tr>
+{{- end}}
+</table>
+{{end}}
+</body>
+</html>
+`))
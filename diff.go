@@ -0,0 +1,273 @@
+package ramlapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/buddhamagnet/raml"
+)
+
+// Change is a difference between two versions of an API.
+type Change struct {
+	// Breaking is true if clients of the old version may fail against
+	// the new one.
+	Breaking bool `json:"breaking"`
+
+	// Endpoint is the verb and path affected, such as "GET /users/{id}".
+	Endpoint string `json:"endpoint"`
+
+	Message string `json:"message"`
+}
+
+func (c Change) String() string {
+	kind := "non-breaking"
+	if c.Breaking {
+		kind = "breaking"
+	}
+	return fmt.Sprintf("%s: %s: %s", kind, c.Endpoint, c.Message)
+}
+
+// Diff compares two versions of an API, from and to, and lists the changes
+// to its endpoints: endpoints, parameters, bodies and response codes added
+// or removed, parameters that became required and constraints that
+// changed. Changes that may break existing clients, such as removed
+// endpoints, newly required parameters, narrowed enums and patterns,
+// changed types and removed response codes, are marked as breaking.
+// Endpoints are compared with resource types and traits applied.
+func Diff(from, to *raml.APIDefinition) []Change {
+	oldMethods, newMethods := diffMethods(from), diffMethods(to)
+
+	d := &differ{}
+	for _, key := range sortedKeys(oldMethods) {
+		if _, ok := newMethods[key]; !ok {
+			d.endpoint = key
+			d.add(true, "endpoint removed")
+		}
+	}
+	for _, key := range sortedKeys(newMethods) {
+		d.endpoint = key
+		o, ok := oldMethods[key]
+		if !ok {
+			d.add(false, "endpoint added")
+			continue
+		}
+		d.method(o, newMethods[key])
+	}
+	return d.changes
+}
+
+// BreakingChanges reports whether any of changes is breaking.
+func BreakingChanges(changes []Change) bool {
+	for _, c := range changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// diffMethod is a method with the URI parameters it inherits.
+type diffMethod struct {
+	method        *raml.Method
+	uriParameters map[string]raml.NamedParameter
+}
+
+// diffMethods returns the methods of an API keyed by verb and full path.
+func diffMethods(api *raml.APIDefinition) map[string]*diffMethod {
+	api = raml.Expanded(api)
+
+	methods := make(map[string]*diffMethod)
+	var walk func(path string, r *raml.Resource, uriParams map[string]raml.NamedParameter)
+	walk = func(path string, r *raml.Resource, uriParams map[string]raml.NamedParameter) {
+		params := make(map[string]raml.NamedParameter, len(uriParams)+len(r.UriParameters))
+		for name, p := range uriParams {
+			params[name] = p
+		}
+		for name, p := range r.UriParameters {
+			params[name] = p
+		}
+		for _, m := range r.Methods() {
+			methods[m.Name+" "+path] = &diffMethod{m, params}
+		}
+		for nested, child := range r.Nested {
+			walk(path+nested, child, params)
+		}
+	}
+	for path, r := range api.Resources {
+		r := r
+		walk(path, &r, nil)
+	}
+	return methods
+}
+
+type differ struct {
+	endpoint string
+	changes  []Change
+}
+
+func (d *differ) add(breaking bool, format string, args ...interface{}) {
+	d.changes = append(d.changes, Change{breaking, d.endpoint, fmt.Sprintf(format, args...)})
+}
+
+func (d *differ) method(o, n *diffMethod) {
+	d.params("URI parameter", o.uriParameters, n.uriParameters)
+	d.params("query parameter", o.method.QueryParameters, n.method.QueryParameters)
+	d.params("header", headerParams(o.method.Headers), headerParams(n.method.Headers))
+	d.bodies("request", o.method.Bodies, n.method.Bodies, true)
+
+	for _, code := range sortedCodes(o.method.Responses) {
+		if _, ok := n.method.Responses[code]; !ok {
+			d.add(true, "response %d removed", code)
+		}
+	}
+	for _, code := range sortedCodes(n.method.Responses) {
+		oldResponse, ok := o.method.Responses[code]
+		if !ok {
+			d.add(false, "response %d added", code)
+			continue
+		}
+		d.bodies(fmt.Sprintf("response %d", code), oldResponse.Bodies, n.method.Responses[code].Bodies, false)
+	}
+}
+
+// bodies compares the media types of request or response bodies, and the
+// form parameters of request bodies.
+func (d *differ) bodies(what string, o, n raml.Bodies, request bool) {
+	for _, mediaType := range sortedKeys(o.ForMIMEType) {
+		if _, ok := n.ForMIMEType[mediaType]; !ok {
+			d.add(true, "%s media type %s removed", what, mediaType)
+		}
+	}
+	for _, mediaType := range sortedKeys(n.ForMIMEType) {
+		oldBody, ok := o.ForMIMEType[mediaType]
+		if !ok {
+			d.add(false, "%s media type %s added", what, mediaType)
+			continue
+		}
+		if request {
+			d.params("form parameter", oldBody.FormParameters, n.ForMIMEType[mediaType].FormParameters)
+		}
+	}
+}
+
+func (d *differ) params(what string, o, n map[string]raml.NamedParameter) {
+	for _, name := range sortedKeys(o) {
+		if _, ok := n[name]; !ok {
+			d.add(false, "%s %s removed", what, name)
+		}
+	}
+	for _, name := range sortedKeys(n) {
+		np := n[name]
+		op, ok := o[name]
+		if !ok {
+			if np.Required {
+				d.add(true, "required %s %s added", what, name)
+			} else {
+				d.add(false, "optional %s %s added", what, name)
+			}
+			continue
+		}
+		d.param(what+" "+name, &op, &np)
+	}
+}
+
+// param compares the constraints of a parameter present in both versions.
+func (d *differ) param(what string, o, n *raml.NamedParameter) {
+	if !o.Required && n.Required {
+		d.add(true, "%s is now required", what)
+	}
+	if oldType, newType := paramType(o), paramType(n); oldType != newType {
+		d.add(true, "%s type changed from %s to %s", what, oldType, newType)
+	}
+
+	if len(n.Enum) > 0 {
+		newValues := make(map[string]bool)
+		for _, v := range n.Enum {
+			newValues[fmt.Sprint(v)] = true
+		}
+		var removed []string
+		for _, v := range o.Enum {
+			if !newValues[fmt.Sprint(v)] {
+				removed = append(removed, fmt.Sprint(v))
+			}
+		}
+		switch {
+		case len(o.Enum) == 0:
+			d.add(true, "%s restricted to %s", what, enumString(n.Enum))
+		case len(removed) > 0:
+			d.add(true, "%s enum narrowed, %s removed", what, strings.Join(removed, ", "))
+		case len(n.Enum) > len(o.Enum):
+			d.add(false, "%s enum widened to %s", what, enumString(n.Enum))
+		}
+	} else if len(o.Enum) > 0 {
+		d.add(false, "%s enum removed", what)
+	}
+
+	oldPattern, newPattern := stringValue(o.Pattern), stringValue(n.Pattern)
+	switch {
+	case oldPattern == newPattern:
+	case newPattern == "":
+		d.add(false, "%s pattern removed", what)
+	case oldPattern == "":
+		d.add(true, "%s pattern %s added", what, newPattern)
+	default:
+		d.add(true, "%s pattern changed from %s to %s", what, oldPattern, newPattern)
+	}
+
+	if narrowedInt(o.MinLength, n.MinLength, 1) || narrowedInt(o.MaxLength, n.MaxLength, -1) {
+		d.add(true, "%s length narrowed", what)
+	}
+	if narrowedFloat(o.Minimum, n.Minimum, 1) || narrowedFloat(o.Maximum, n.Maximum, -1) {
+		d.add(true, "%s range narrowed", what)
+	}
+}
+
+func paramType(p *raml.NamedParameter) string {
+	if p.Type == "" {
+		return "string"
+	}
+	return p.Type
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func enumString(enum []raml.Any) string {
+	values := make([]string, len(enum))
+	for i, v := range enum {
+		values[i] = fmt.Sprint(v)
+	}
+	return strings.Join(values, ", ")
+}
+
+// narrowedInt reports whether a bound was added or moved in direction,
+// 1 for a minimum and -1 for a maximum.
+func narrowedInt(o, n *int, direction int) bool {
+	return n != nil && (o == nil || (*n-*o)*direction > 0)
+}
+
+func narrowedFloat(o, n *float64, direction float64) bool {
+	return n != nil && (o == nil || (*n-*o)*direction > 0)
+}
+
+func headerParams(headers map[raml.HTTPHeader]raml.Header) map[string]raml.NamedParameter {
+	params := make(map[string]raml.NamedParameter, len(headers))
+	for name, h := range headers {
+		params[strings.ToLower(string(name))] = raml.NamedParameter(h)
+	}
+	return params
+}
+
+func sortedCodes(responses map[raml.HTTPCode]raml.Response) []raml.HTTPCode {
+	codes := make([]raml.HTTPCode, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
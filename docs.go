@@ -0,0 +1,293 @@
+package ramlapi
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/buddhamagnet/raml"
+)
+
+// WriteMarkdown writes a Markdown reference for an API: its documentation
+// sections followed by every endpoint with its parameters, bodies,
+// responses and examples. Resource types and traits are applied to a copy
+// of the definition, so each endpoint is described in full.
+func WriteMarkdown(w io.Writer, api *raml.APIDefinition) error {
+	return markdownTemplate.Execute(w, newDocPage(api))
+}
+
+// WriteHTML writes the same reference as WriteMarkdown as a standalone
+// HTML page.
+func WriteHTML(w io.Writer, api *raml.APIDefinition) error {
+	return htmlTemplate.Execute(w, newDocPage(api))
+}
+
+type docPage struct {
+	Title, Version, BaseURI string
+	Sections                []raml.Documentation
+	Resources               []*docResource
+
+	mediaType string
+	schemas   map[string]string
+}
+
+type docResource struct {
+	Path, DisplayName, Description string
+	URIParameters                  []*docParam
+	Methods                        []*docMethod
+}
+
+type docMethod struct {
+	Verb, Handler, DisplayName, Description string
+	SecuredBy                               []string
+	QueryParameters, Headers                []*docParam
+	Bodies                                  []*docBody
+	Responses                               []*docResponse
+}
+
+type docParam struct {
+	Name, Type, Description, Pattern, Default, Example string
+	Required                                           bool
+	Enum                                               []string
+}
+
+type docBody struct {
+	MediaType, Schema, Example string
+	FormParameters             []*docParam
+}
+
+type docResponse struct {
+	Code        int
+	Status      string
+	Description string
+	Headers     []*docParam
+	Bodies      []*docBody
+}
+
+func newDocPage(api *raml.APIDefinition) *docPage {
+	api = raml.Expanded(api)
+
+	page := &docPage{
+		Title:     api.Title,
+		Version:   api.Version,
+		BaseURI:   api.BaseUri,
+		Sections:  api.Documentation,
+		mediaType: api.MediaType,
+		schemas:   make(map[string]string),
+	}
+	for _, named := range api.Schemas {
+		for name, schema := range named {
+			page.schemas[name] = schema
+		}
+	}
+	for _, path := range sortedKeys(api.Resources) {
+		resource := api.Resources[path]
+		page.addResource(path, &resource, nil)
+	}
+	return page
+}
+
+// addResource adds a resource and its children. URI parameters are
+// inherited from the parent resources.
+func (page *docPage) addResource(path string, resource *raml.Resource, uriParams []*docParam) {
+	// URI parameters are always required
+	params := docParams(resource.UriParameters)
+	for _, p := range params {
+		p.Required = true
+	}
+	uriParams = append(uriParams[:len(uriParams):len(uriParams)], params...)
+	r := &docResource{
+		Path:          path,
+		DisplayName:   resource.DisplayName,
+		Description:   resource.Description,
+		URIParameters: uriParams,
+	}
+	for _, m := range resource.Methods() {
+		r.Methods = append(r.Methods, page.newDocMethod(path, m))
+	}
+	page.Resources = append(page.Resources, r)
+
+	for _, nested := range sortedKeys(resource.Nested) {
+		page.addResource(path+nested, resource.Nested[nested], uriParams)
+	}
+}
+
+func (page *docPage) newDocMethod(path string, m *raml.Method) *docMethod {
+	dm := &docMethod{
+		Verb:            m.Name,
+		Handler:         DefaultNamer(path, m),
+		DisplayName:     m.DisplayName,
+		Description:     m.Description,
+		QueryParameters: docParams(m.QueryParameters),
+		Headers:         docHeaders(m.Headers),
+		Bodies:          page.docBodies(m.Bodies),
+	}
+	for _, s := range m.SecuredBy {
+		if s.Name == "" || s.Name == "null" {
+			dm.SecuredBy = append(dm.SecuredBy, "none")
+			continue
+		}
+		dm.SecuredBy = append(dm.SecuredBy, s.Name)
+	}
+
+	codes := make([]int, 0, len(m.Responses))
+	for code := range m.Responses {
+		codes = append(codes, int(code))
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		response := m.Responses[raml.HTTPCode(code)]
+		dm.Responses = append(dm.Responses, &docResponse{
+			Code:        code,
+			Status:      http.StatusText(code),
+			Description: response.Description,
+			Headers:     docHeaders(response.Headers),
+			Bodies:      page.docBodies(response.Bodies),
+		})
+	}
+	return dm
+}
+
+func docParams(params map[string]raml.NamedParameter) []*docParam {
+	var list []*docParam
+	for _, name := range sortedKeys(params) {
+		list = append(list, newDocParam(name, params[name]))
+	}
+	return list
+}
+
+func docHeaders(headers map[raml.HTTPHeader]raml.Header) []*docParam {
+	params := make(map[string]raml.NamedParameter, len(headers))
+	for name, header := range headers {
+		params[string(name)] = raml.NamedParameter(header)
+	}
+	return docParams(params)
+}
+
+func newDocParam(name string, param raml.NamedParameter) *docParam {
+	p := &docParam{
+		Name:        name,
+		Type:        param.Type,
+		Description: param.Description,
+		Example:     param.Example,
+		Required:    param.Required,
+	}
+	if p.Type == "" {
+		p.Type = "string"
+	}
+	if param.Pattern != nil {
+		p.Pattern = *param.Pattern
+	}
+	if param.Default != nil {
+		p.Default = fmt.Sprint(param.Default)
+	}
+	for _, e := range param.Enum {
+		p.Enum = append(p.Enum, fmt.Sprint(e))
+	}
+	return p
+}
+
+// Summary describes a parameter and its constraints in a line.
+func (p *docParam) Summary() string {
+	parts := []string{strings.TrimSpace(p.Description)}
+	if len(p.Enum) > 0 {
+		parts = append(parts, "One of "+strings.Join(p.Enum, ", ")+".")
+	}
+	if p.Pattern != "" {
+		parts = append(parts, "Matches "+p.Pattern+".")
+	}
+	if p.Default != "" {
+		parts = append(parts, "Default "+p.Default+".")
+	}
+	if p.Example != "" {
+		parts = append(parts, "Example "+p.Example+".")
+	}
+	return strings.TrimSpace(strings.Join(parts, " "))
+}
+
+// docBodies lists bodies by media type. A body without one has the API's
+// default media type. Schemas referred to by name are looked up.
+func (page *docPage) docBodies(bodies raml.Bodies) []*docBody {
+	var list []*docBody
+	if bodies.DefaultSchema != "" || bodies.DefaultExample != "" || len(bodies.DefaultFormParameters) > 0 {
+		list = append(list, &docBody{
+			MediaType:      page.mediaType,
+			Schema:         page.schema(bodies.DefaultSchema),
+			Example:        bodies.DefaultExample,
+			FormParameters: docParams(bodies.DefaultFormParameters),
+		})
+	}
+	for _, mediaType := range sortedKeys(bodies.ForMIMEType) {
+		body := bodies.ForMIMEType[mediaType]
+		list = append(list, &docBody{
+			MediaType:      mediaType,
+			Schema:         page.schema(body.Schema),
+			Example:        body.Example,
+			FormParameters: docParams(body.FormParameters),
+		})
+	}
+	return list
+}
+
+func (page *docPage) schema(s string) string {
+	if named, ok := page.schemas[s]; ok {
+		return named
+	}
+	return s
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// anchor returns an HTML id for an endpoint.
+func anchor(verb, path string) string {
+	return strings.ToLower(verb) + "-" + strings.Trim(placeholder.ReplaceAllString(strings.ReplaceAll(path, "/", "-"), "$1"), "-")
+}
+
+// paragraphs splits text on blank lines.
+func paragraphs(s string) []string {
+	var list []string
+	for _, p := range strings.Split(strings.TrimSpace(s), "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// fence returns a Markdown code fence long enough to hold s.
+func fence(s string) string {
+	f := "```"
+	for strings.Contains(s, f) {
+		f += "`"
+	}
+	return f
+}
+
+// cell escapes text for a Markdown table cell.
+func cell(s string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(s), " "), "|", `\|`)
+}
+
+var docFuncs = map[string]interface{}{
+	"anchor":     anchor,
+	"paragraphs": paragraphs,
+	"fence":      fence,
+	"cell":       cell,
+	"join":       strings.Join,
+	"trim":       strings.TrimSpace,
+}
+
+var markdownTemplate = template.Must(template.New("markdown").Funcs(docFuncs).Parse(markdownDocs))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(docFuncs).Parse(htmlDocs))
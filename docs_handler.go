@@ -0,0 +1,72 @@
+package ramlapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/buddhamagnet/raml"
+)
+
+// DocsHandler serves the documentation of an API from the service that
+// implements it, so consumers always see the spec actually deployed. It
+// serves
+//
+//	/               the HTML reference written by WriteHTML
+//	/docs.md        the Markdown reference written by WriteMarkdown
+//	/api.raml       the RAML, with includes resolved
+//	/endpoints.json the endpoints Build finds, as JSON
+//
+// relative to where it is mounted, for example:
+//
+//	mux.Handle("/docs/", http.StripPrefix("/docs", ramlapi.DocsHandler(api)))
+//
+// Everything is rendered when the handler is created. The RAML is only
+// available for definitions parsed from RAML.
+func DocsHandler(api *raml.APIDefinition) http.Handler {
+	raw, rawErr := api.Resolved()
+
+	var html, markdown bytes.Buffer
+	htmlErr := WriteHTML(&html, api)
+	markdownErr := WriteMarkdown(&markdown, api)
+
+	endpoints := []*Endpoint{}
+	endpointsErr := Build(api, func(ep *Endpoint) {
+		endpoints = append(endpoints, ep)
+	}, Quiet())
+	list, _ := json.MarshalIndent(endpoints, "", "  ")
+
+	serve := func(w http.ResponseWriter, contentType string, b []byte, err error) {
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Write(b)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		switch r.URL.Path {
+		case "", "/":
+			serve(w, "text/html; charset=utf-8", html.Bytes(), htmlErr)
+		case "/docs.md":
+			serve(w, "text/markdown; charset=utf-8", markdown.Bytes(), markdownErr)
+		case "/api.raml":
+			if raw == nil && rawErr == nil {
+				http.NotFound(w, r)
+				return
+			}
+			serve(w, "application/raml+yaml", raw, rawErr)
+		case "/endpoints.json":
+			serve(w, "application/json", list, endpointsErr)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
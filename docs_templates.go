@@ -0,0 +1,112 @@
+package ramlapi
+
+const markdownDocs = `# {{.Title}}
+{{if .Version}}
+Version: {{.Version}}
+{{end}}{{if .BaseURI}}
+Base URI: ` + "`{{.BaseURI}}`" + `
+{{end}}{{range .Sections}}
+## {{.Title}}
+
+{{trim .Content}}
+{{end}}
+## Endpoints
+{{range .Resources}}{{$r := .}}{{range .Methods}}
+### {{.Verb}} {{$r.Path}}
+{{if .DisplayName}}
+**{{.DisplayName}}**
+{{end}}{{if .Description}}
+{{trim .Description}}
+{{end}}
+Handler: ` + "`{{.Handler}}`" + `
+{{if .SecuredBy}}
+Secured by: {{join .SecuredBy ", "}}
+{{end}}{{if $r.URIParameters}}
+#### URI parameters
+{{template "params" $r.URIParameters}}{{end}}{{if .QueryParameters}}
+#### Query parameters
+{{template "params" .QueryParameters}}{{end}}{{if .Headers}}
+#### Headers
+{{template "params" .Headers}}{{end}}{{if .Bodies}}
+#### Body
+{{template "bodies" .Bodies}}{{end}}{{if .Responses}}
+#### Responses
+{{range .Responses}}
+##### {{.Code}} {{.Status}}
+{{if .Description}}
+{{trim .Description}}
+{{end}}{{if .Headers}}
+Headers:
+{{template "params" .Headers}}{{end}}{{template "bodies" .Bodies}}{{end}}{{end}}{{end}}{{end}}
+{{- define "params"}}
+| Name | Type | Required | Description |
+| --- | --- | --- | --- |
+{{range .}}| {{.Name}} | {{.Type}} | {{if .Required}}yes{{else}}no{{end}} | {{cell .Summary}} |
+{{end}}{{end}}
+{{- define "bodies"}}{{range .}}{{if .MediaType}}
+Media type: ` + "`{{.MediaType}}`" + `
+{{end}}{{if .FormParameters}}{{template "params" .FormParameters}}{{end}}{{if .Schema}}
+Schema:
+
+{{fence .Schema}}
+{{trim .Schema}}
+{{fence .Schema}}
+{{end}}{{if .Example}}
+Example:
+
+{{fence .Example}}
+{{trim .Example}}
+{{fence .Example}}
+{{end}}{{end}}{{end}}`
+
+const htmlDocs = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 60em; margin: 0 auto; padding: 1em; }
+pre { background: #f5f5f5; padding: 0.5em; overflow: auto; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ddd; padding: 0.25em 0.5em; text-align: left; }
+.verb { font-family: monospace; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Version}}<p>Version: {{.Version}}</p>
+{{end}}{{if .BaseURI}}<p>Base URI: <code>{{.BaseURI}}</code></p>
+{{end}}{{range .Sections}}<h2>{{.Title}}</h2>
+{{range paragraphs .Content}}<p>{{.}}</p>
+{{end}}{{end}}<h2>Endpoints</h2>
+<ul>
+{{range .Resources}}{{$r := .}}{{range .Methods}}<li><a href="#{{anchor .Verb $r.Path}}"><span class="verb">{{.Verb}}</span> {{$r.Path}}</a></li>
+{{end}}{{end}}</ul>
+{{range .Resources}}{{$r := .}}{{range .Methods}}<section id="{{anchor .Verb $r.Path}}">
+<h3><span class="verb">{{.Verb}}</span> {{$r.Path}}</h3>
+{{if .DisplayName}}<p><strong>{{.DisplayName}}</strong></p>
+{{end}}{{range paragraphs .Description}}<p>{{.}}</p>
+{{end}}<p>Handler: <code>{{.Handler}}</code></p>
+{{if .SecuredBy}}<p>Secured by: {{join .SecuredBy ", "}}</p>
+{{end}}{{if $r.URIParameters}}<h4>URI parameters</h4>
+{{template "params" $r.URIParameters}}{{end}}{{if .QueryParameters}}<h4>Query parameters</h4>
+{{template "params" .QueryParameters}}{{end}}{{if .Headers}}<h4>Headers</h4>
+{{template "params" .Headers}}{{end}}{{if .Bodies}}<h4>Body</h4>
+{{template "bodies" .Bodies}}{{end}}{{if .Responses}}<h4>Responses</h4>
+{{range .Responses}}<h5>{{.Code}} {{.Status}}</h5>
+{{range paragraphs .Description}}<p>{{.}}</p>
+{{end}}{{if .Headers}}<p>Headers:</p>
+{{template "params" .Headers}}{{end}}{{template "bodies" .Bodies}}{{end}}{{end}}</section>
+{{end}}{{end}}</body>
+</html>
+{{define "params"}}<table>
+<tr><th>Name</th><th>Type</th><th>Required</th><th>Description</th></tr>
+{{range .}}<tr><td>{{.Name}}</td><td>{{.Type}}</td><td>{{if .Required}}yes{{else}}no{{end}}</td><td>{{.Summary}}</td></tr>
+{{end}}</table>
+{{end}}
+{{- define "bodies"}}{{range .}}{{if .MediaType}}<p>Media type: <code>{{.MediaType}}</code></p>
+{{end}}{{if .FormParameters}}{{template "params" .FormParameters}}{{end}}{{if .Schema}}<p>Schema:</p>
+<pre>{{trim .Schema}}</pre>
+{{end}}{{if .Example}}<p>Example:</p>
+<pre>{{trim .Example}}</pre>
+{{end}}{{end}}{{end}}`
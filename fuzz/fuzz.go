@@ -0,0 +1,350 @@
+// Package fuzz drives an HTTP handler with requests generated from the
+// constraints of a RAML spec, and reports panics, server errors and
+// responses that break the spec.
+//
+// Requests are built for each Endpoint from its URI parameters, query
+// parameters and headers. A request is valid if every required parameter
+// is present and every value passes raml.CheckParameter. Valid requests
+// must get one of the declared response codes, while invalid ones must
+// get a client error. Endpoints from ramlapi.Build include the parameters
+// and responses of their traits and resource types.
+package fuzz
+
+import (
+	"fmt"
+	"math/rand"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/EconomistDigitalSolutions/ramlapi"
+	"github.com/buddhamagnet/raml"
+)
+
+var placeholder = regexp.MustCompile(`{([^}]+)}`)
+
+// Request is a generated request to an endpoint.
+type Request struct {
+	*http.Request
+	Endpoint *ramlapi.Endpoint
+
+	// Invalid says why the request breaks the spec, or is empty if it
+	// doesn't.
+	Invalid string
+}
+
+// Failure is a response that broke the spec, or a panic.
+type Failure struct {
+	Endpoint *ramlapi.Endpoint
+	Method   string
+	Target   string
+
+	// Status is the response code, or 0 if the handler panicked.
+	Status int
+	Reason string
+}
+
+func (f *Failure) Error() string {
+	return fmt.Sprintf("%s %s: %s", f.Method, f.Target, f.Reason)
+}
+
+// NewRequest builds a request to ep. Each byte of data decides how a
+// parameter is set: to a valid value, left out, to a value breaking one of
+// its constraints or to arbitrary text taken from data. When data runs
+// out the remaining parameters get valid values, so empty data gives a
+// valid request.
+func NewRequest(ep *ramlapi.Endpoint, data []byte) *Request {
+	d := &decoder{data: data}
+	r := &Request{Endpoint: ep}
+	invalid := func(format string, args ...interface{}) {
+		if r.Invalid == "" {
+			r.Invalid = fmt.Sprintf(format, args...)
+		}
+	}
+
+	uri := make(map[string]string)
+	for _, p := range sortedParams(ep.URIParameters) {
+		v, ok := d.value(p.Definition)
+		if !ok {
+			// URI parameters can't be left out, so send an empty one
+			v = ""
+		}
+		if err := raml.CheckParameter(p.Definition, v); err != nil || v == "" {
+			invalid("URI parameter %s: %s", p.Key, problem(err))
+		}
+		uri[p.Key] = v
+	}
+	target := placeholder.ReplaceAllStringFunc(ep.Path, func(s string) string {
+		return url.PathEscape(uri[s[1:len(s)-1]])
+	})
+
+	query := url.Values{}
+	for _, p := range sortedParams(ep.QueryParameters) {
+		v, ok := d.value(p.Definition)
+		switch {
+		case !ok && p.Required:
+			invalid("missing query parameter %s", p.Key)
+		case !ok:
+		default:
+			if err := raml.CheckParameter(p.Definition, v); err != nil {
+				invalid("query parameter %s: %s", p.Key, err)
+			}
+			query.Set(p.Key, v)
+		}
+	}
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	mediaType, body := requestBody(ep)
+	r.Request = httptest.NewRequest(ep.Verb, target, strings.NewReader(body))
+	if mediaType != "" {
+		r.Header.Set("Content-Type", mediaType)
+	}
+
+	for _, p := range sortedParams(ep.Headers) {
+		v, ok := d.value(p.Definition)
+		switch {
+		case !ok && p.Required:
+			invalid("missing header %s", p.Key)
+		case !ok:
+		default:
+			if err := raml.CheckParameter(p.Definition, v); err != nil {
+				invalid("header %s: %s", p.Key, err)
+			}
+			r.Header.Set(p.Key, v)
+		}
+	}
+	return r
+}
+
+// Check sends r to h and returns a failure if h panics, returns a server
+// error, accepts an invalid request or responds in a way the spec doesn't
+// declare: an undeclared status code, a missing required header or an
+// undeclared Content-Type. It returns nil if the response is fine.
+func Check(h http.Handler, r *Request) (failure *Failure) {
+	fail := func(status int, format string, args ...interface{}) *Failure {
+		return &Failure{r.Endpoint, r.Method, r.URL.RequestURI(), status, fmt.Sprintf(format, args...)}
+	}
+	defer func() {
+		if v := recover(); v != nil {
+			failure = fail(0, "panic: %v", v)
+		}
+	}()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r.Request)
+	code := w.Code
+
+	var responses map[raml.HTTPCode]raml.Response
+	if r.Endpoint.Method != nil {
+		responses = r.Endpoint.Method.Responses
+	}
+	var declared, clientCodes []int
+	for c := range responses {
+		declared = append(declared, int(c))
+		if c >= 400 && c < 500 {
+			clientCodes = append(clientCodes, int(c))
+		}
+	}
+
+	switch {
+	case code >= 500:
+		return fail(code, "server error %d", code)
+	case r.Invalid != "" && code < 400:
+		return fail(code, "accepted invalid request (%s) with %d", r.Invalid, code)
+	case r.Invalid != "":
+		if len(clientCodes) > 0 && !contains(clientCodes, code) {
+			return fail(code, "rejected invalid request (%s) with undeclared status %d", r.Invalid, code)
+		}
+		return nil
+	case len(declared) > 0 && !contains(declared, code):
+		return fail(code, "undeclared status %d", code)
+	}
+
+	response, ok := responses[raml.HTTPCode(code)]
+	if !ok {
+		return nil
+	}
+	for _, name := range sortedHeaders(response.Headers) {
+		if response.Headers[raml.HTTPHeader(name)].Required && w.Header().Get(name) == "" {
+			return fail(code, "missing response header %s", name)
+		}
+	}
+	if len(response.Bodies.ForMIMEType) > 0 && w.Body.Len() > 0 {
+		mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+		if _, ok := response.Bodies.ForMIMEType[mediaType]; !ok {
+			return fail(code, "undeclared Content-Type %q", w.Header().Get("Content-Type"))
+		}
+	}
+	return nil
+}
+
+// Run sends h a valid request for each endpoint, then requests leaving out
+// each parameter and breaking each of its constraints, then n random
+// requests generated from seed. It returns the failures found.
+func Run(h http.Handler, endpoints []*ramlapi.Endpoint, n int, seed int64) []*Failure {
+	rnd := rand.New(rand.NewSource(seed))
+	var failures []*Failure
+	for _, ep := range endpoints {
+		corpus := Corpus(ep)
+		for i := 0; i < n; i++ {
+			data := make([]byte, rnd.Intn(64))
+			rnd.Read(data)
+			corpus = append(corpus, data)
+		}
+		for _, data := range corpus {
+			if f := Check(h, NewRequest(ep, data)); f != nil {
+				failures = append(failures, f)
+			}
+		}
+	}
+	return failures
+}
+
+// Corpus returns the data for a valid request to ep followed by requests
+// leaving out each parameter and setting it to each of its invalid values.
+func Corpus(ep *ramlapi.Endpoint) [][]byte {
+	var params []raml.NamedParameter
+	for _, list := range [][]*ramlapi.Parameter{ep.URIParameters, ep.QueryParameters, ep.Headers} {
+		for _, p := range sortedParams(list) {
+			params = append(params, p.Definition)
+		}
+	}
+
+	corpus := [][]byte{{}}
+	for i, p := range params {
+		// Earlier parameters take one byte each to be valid
+		prefix := make([]byte, i, i+2)
+		corpus = append(corpus, append(prefix, omit))
+		for j := range ramlapi.InvalidValues(p) {
+			corpus = append(corpus, append(prefix[:i:i], breakConstraint, byte(j)))
+		}
+	}
+	return corpus
+}
+
+// Fuzz runs a native fuzz test against h, seeded with the Corpus of each
+// endpoint. Call it from a fuzz target:
+//
+//	func FuzzAPI(f *testing.F) {
+//		fuzz.Fuzz(f, handler, endpoints)
+//	}
+func Fuzz(f *testing.F, h http.Handler, endpoints []*ramlapi.Endpoint) {
+	if len(endpoints) == 0 {
+		f.Fatal("fuzz: no endpoints")
+	}
+	for i, ep := range endpoints {
+		for _, data := range Corpus(ep) {
+			f.Add(uint16(i), data)
+		}
+	}
+	f.Fuzz(func(t *testing.T, i uint16, data []byte) {
+		ep := endpoints[int(i)%len(endpoints)]
+		if failure := Check(h, NewRequest(ep, data)); failure != nil {
+			t.Error(failure)
+		}
+	})
+}
+
+// Choices for a parameter, taken from a byte of data modulo 8. Anything
+// else gives a valid value.
+const (
+	omit            = 5
+	breakConstraint = 6
+	arbitrary       = 7
+)
+
+type decoder struct {
+	data []byte
+}
+
+func (d *decoder) next() byte {
+	if len(d.data) == 0 {
+		return 0
+	}
+	b := d.data[0]
+	d.data = d.data[1:]
+	return b
+}
+
+// value returns the value to send for a parameter, or false to leave it
+// out.
+func (d *decoder) value(p raml.NamedParameter) (string, bool) {
+	switch d.next() % 8 {
+	case omit:
+		return "", false
+	case breakConstraint:
+		if invalid := ramlapi.InvalidValues(p); len(invalid) > 0 {
+			return invalid[int(d.next())%len(invalid)].Value, true
+		}
+	case arbitrary:
+		// Printable ASCII, so the value is also a valid header
+		n := int(d.next() % 16)
+		var b strings.Builder
+		for i := 0; i < n; i++ {
+			b.WriteByte(' ' + d.next()%95)
+		}
+		return b.String(), true
+	}
+	v, _ := ramlapi.SampleValue(p)
+	return v, true
+}
+
+// requestBody returns the first example body of an endpoint's method.
+func requestBody(ep *ramlapi.Endpoint) (string, string) {
+	if ep.Method == nil {
+		return "", ""
+	}
+	bodies := ep.Method.Bodies
+	mediaTypes := make([]string, 0, len(bodies.ForMIMEType))
+	for mediaType := range bodies.ForMIMEType {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+	for _, mediaType := range mediaTypes {
+		if example := bodies.ForMIMEType[mediaType].Example; example != "" {
+			return mediaType, example
+		}
+	}
+	if bodies.DefaultExample != "" {
+		return "application/json", bodies.DefaultExample
+	}
+	return "", ""
+}
+
+func problem(err error) string {
+	if err == nil {
+		return "empty"
+	}
+	return err.Error()
+}
+
+func contains(codes []int, code int) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedParams(params []*ramlapi.Parameter) []*ramlapi.Parameter {
+	sorted := append([]*ramlapi.Parameter(nil), params...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+	return sorted
+}
+
+func sortedHeaders(headers map[raml.HTTPHeader]raml.Header) []string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,163 @@
+package fuzz_test
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/EconomistDigitalSolutions/ramlapi"
+	. "github.com/EconomistDigitalSolutions/ramlapi/fuzz"
+)
+
+// endpoints builds the endpoints of the fuzz fixture.
+func endpoints(t testing.TB) []*ramlapi.Endpoint {
+	api, err := ramlapi.Process("../fixtures/fuzz.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var eps []*ramlapi.Endpoint
+	err = ramlapi.Build(api, func(ep *ramlapi.Endpoint) {
+		eps = append(eps, ep)
+	}, ramlapi.Quiet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return eps
+}
+
+// handler serves the fixture. With strict unset it doesn't check X-Mode
+// and indexes a fixed-size array with the limit.
+func handler(strict bool) http.Handler {
+	items := func(w http.ResponseWriter, r *http.Request) {
+		limit := 10
+		if s, ok := r.URL.Query()["limit"]; ok {
+			n, err := strconv.Atoi(s[0])
+			if err != nil || (strict && (n < 1 || n > 100)) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+		var page [100]int
+		_ = page[limit-1]
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[]"))
+	}
+	item := func(w http.ResponseWriter, r *http.Request, id string) {
+		if id == "" || strings.Trim(id, "0123456789") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if mode := r.Header.Get("X-Mode"); strict && mode != "full" && mode != "brief" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("ETag", `"1"`)
+		w.WriteHeader(http.StatusOK)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/items":
+			items(w, r)
+		case strings.HasPrefix(r.URL.Path, "/items/"):
+			item(w, r, strings.TrimPrefix(r.URL.Path, "/items/"))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func TestNewRequest(t *testing.T) {
+	var ep *ramlapi.Endpoint
+	for _, e := range endpoints(t) {
+		if e.Path == "/items/{id}" {
+			ep = e
+		}
+	}
+
+	r := NewRequest(ep, nil)
+	if r.Invalid != "" || r.URL.Path != "/items/0" || r.Header.Get("X-Mode") != "full" {
+		t.Errorf("valid request: got %s %q, X-Mode %q", r.URL, r.Invalid, r.Header.Get("X-Mode"))
+	}
+
+	// Valid id, then leave out the header
+	r = NewRequest(ep, []byte{0, 5})
+	if r.Invalid != "missing header X-Mode" {
+		t.Errorf("missing header: got %q", r.Invalid)
+	}
+
+	// Break the pattern of the id
+	r = NewRequest(ep, []byte{6, 0})
+	if !strings.HasPrefix(r.Invalid, "URI parameter id:") {
+		t.Errorf("invalid id: got %s %q", r.URL, r.Invalid)
+	}
+}
+
+func TestCorpus(t *testing.T) {
+	for _, ep := range endpoints(t) {
+		corpus := Corpus(ep)
+		if r := NewRequest(ep, corpus[0]); r.Invalid != "" {
+			t.Errorf("%s %s: first request is invalid: %s", ep.Verb, ep.Path, r.Invalid)
+		}
+		var invalid int
+		for _, data := range corpus[1:] {
+			if NewRequest(ep, data).Invalid != "" {
+				invalid++
+			}
+		}
+		if invalid == 0 {
+			t.Errorf("%s %s: no invalid requests in corpus", ep.Verb, ep.Path)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	eps := endpoints(t)
+	if failures := Run(handler(true), eps, 200, 1); len(failures) > 0 {
+		t.Errorf("strict handler: got %d failures, first %s", len(failures), failures[0])
+	}
+
+	reasons := make(map[string]bool)
+	for _, f := range Run(handler(false), eps, 200, 1) {
+		switch {
+		case strings.HasPrefix(f.Reason, "panic: "):
+			reasons["panic"] = true
+		case strings.HasPrefix(f.Reason, "accepted invalid request"):
+			reasons["accepted"] = true
+		default:
+			t.Errorf("unexpected failure: %s", f)
+		}
+	}
+	if !reasons["panic"] || !reasons["accepted"] {
+		t.Errorf("lax handler: got failures %v, want a panic and an accepted invalid request", reasons)
+	}
+}
+
+func TestCheckContract(t *testing.T) {
+	eps := endpoints(t)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/items":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("items"))
+		default:
+			w.WriteHeader(http.StatusTeapot)
+		}
+	})
+
+	tests := map[string]string{
+		"/items":      `undeclared Content-Type "text/plain"`,
+		"/items/{id}": "undeclared status 418",
+	}
+	for _, ep := range eps {
+		f := Check(h, NewRequest(ep, nil))
+		if f == nil || f.Reason != tests[ep.Path] {
+			t.Errorf("%s: got %v, want %s", ep.Path, f, tests[ep.Path])
+		}
+	}
+}
+
+func FuzzItems(f *testing.F) {
+	Fuzz(f, handler(true), endpoints(f))
+}
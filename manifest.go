@@ -0,0 +1,86 @@
+package ramlapi
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/buddhamagnet/raml"
+)
+
+// ManifestEndpoint is an endpoint as listed by Manifest: the Endpoint
+// Build passes to the router function, with its request bodies and
+// declared responses.
+type ManifestEndpoint struct {
+	*Endpoint
+	Bodies    []*ManifestBody     `json:"bodies,omitempty"`
+	Responses []*ManifestResponse `json:"responses,omitempty"`
+}
+
+// ManifestBody is a request or response body of one media type.
+type ManifestBody struct {
+	MediaType string `json:"mediaType"`
+	Schema    string `json:"schema,omitempty"`
+	Example   string `json:"example,omitempty"`
+}
+
+// ManifestResponse is a declared response.
+type ManifestResponse struct {
+	Code        int             `json:"code"`
+	Description string          `json:"description,omitempty"`
+	Headers     []*Parameter    `json:"headers,omitempty"`
+	Bodies      []*ManifestBody `json:"bodies,omitempty"`
+}
+
+// Manifest returns the endpoints Build finds in an API as an indented JSON
+// list of ManifestEndpoints, sorted by path and verb, for tools such as
+// gateways and load testers that need the routes without linking Go code.
+// Options are passed to Build, which doesn't log. Endpoints are listed
+// with resource types and traits applied.
+func Manifest(api *raml.APIDefinition, opts ...Option) ([]byte, error) {
+	endpoints, err := manifestEndpoints(api, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(endpoints, "", "  ")
+}
+
+func manifestEndpoints(api *raml.APIDefinition, opts ...Option) ([]*ManifestEndpoint, error) {
+	// Bodies are listed like the docs list them
+	page := &docPage{mediaType: api.MediaType, schemas: make(map[string]string)}
+	for _, named := range api.Schemas {
+		for name, schema := range named {
+			page.schemas[name] = schema
+		}
+	}
+	bodies := func(b raml.Bodies) []*ManifestBody {
+		var list []*ManifestBody
+		for _, body := range page.docBodies(b) {
+			list = append(list, &ManifestBody{body.MediaType, body.Schema, body.Example})
+		}
+		return list
+	}
+
+	endpoints := []*ManifestEndpoint{}
+	err := Build(api, func(ep *Endpoint) {
+		me := &ManifestEndpoint{Endpoint: ep, Bodies: bodies(ep.Method.Bodies)}
+		for _, code := range sortedCodes(ep.Method.Responses) {
+			response := ep.Method.Responses[code]
+			mr := &ManifestResponse{Code: int(code), Description: response.Description, Bodies: bodies(response.Bodies)}
+			for _, name := range sortedHeaderNames(response.Headers) {
+				param := raml.NamedParameter(response.Headers[raml.HTTPHeader(name)])
+				mr.Headers = append(mr.Headers, newParam(name, &param))
+			}
+			me.Responses = append(me.Responses, mr)
+		}
+		endpoints = append(endpoints, me)
+	}, append([]Option{Quiet()}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(endpoints, func(i, j int) bool {
+		a, b := endpoints[i], endpoints[j]
+		return a.Path < b.Path || (a.Path == b.Path && a.Verb < b.Verb)
+	})
+	return endpoints, nil
+}
@@ -0,0 +1,64 @@
+package ramlapi
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/buddhamagnet/raml"
+)
+
+var annotation = regexp.MustCompile(`x-handler:\s*([A-Za-z_][A-Za-z0-9_]*)`)
+
+// Namer derives a handler name for a method on a resource path. It
+// returns an empty string if it cannot name the method.
+type Namer func(path string, method *raml.Method) string
+
+// DefaultNamer is used to name handlers. It prefers an x-handler annotation,
+// then the displayName and finally a name generated from the verb and path.
+var DefaultNamer = ChainNamers(AnnotationNamer, DisplayNameNamer, VerbPathNamer)
+
+// ChainNamers returns a Namer that tries each namer in turn and
+// uses the first name returned.
+func ChainNamers(namers ...Namer) Namer {
+	return func(path string, method *raml.Method) string {
+		for _, n := range namers {
+			if name := n(path, method); name != "" {
+				return name
+			}
+		}
+		return ""
+	}
+}
+
+// DisplayNameNamer names handlers after the method's displayName property.
+func DisplayNameNamer(path string, method *raml.Method) string {
+	return Variableize(method.DisplayName)
+}
+
+// AnnotationNamer names handlers after an x-handler annotation in the
+// method's description, for example "x-handler: GetUser".
+func AnnotationNamer(path string, method *raml.Method) string {
+	m := annotation.FindStringSubmatch(method.Description)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// VerbPathNamer names handlers after the HTTP verb and resource path,
+// so GET /users/{id} becomes GetUsersById.
+func VerbPathNamer(path string, method *raml.Method) string {
+	name := Variableize(strings.ToLower(method.Name))
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) == 1 && segments[0] == "" {
+		return name + "Root"
+	}
+	for _, s := range segments {
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			name += "By" + Variableize(s[1:len(s)-1])
+			continue
+		}
+		name += Variableize(s)
+	}
+	return name
+}
@@ -0,0 +1,138 @@
+package ramlapi
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/buddhamagnet/raml"
+)
+
+// mediaTypes appends the media types of bodies to list, skipping those
+// already in it. A body without a media type has the API's.
+func (o *options) mediaTypes(list []string, bodies raml.Bodies) []string {
+	add := func(mediaType string) {
+		mediaType = strings.ToLower(mediaType)
+		for _, t := range list {
+			if t == mediaType {
+				return
+			}
+		}
+		list = append(list, mediaType)
+	}
+	if o.mediaType != "" && (bodies.DefaultSchema != "" || bodies.DefaultExample != "" || len(bodies.DefaultFormParameters) > 0) {
+		add(o.mediaType)
+	}
+	for _, mediaType := range sortedKeys(bodies.ForMIMEType) {
+		add(mediaType)
+	}
+	return list
+}
+
+// NegotiatedType returns the response media type Negotiate picked for a
+// request, or an empty string if it didn't pick one.
+func NegotiatedType(ctx context.Context) string {
+	t, _ := ctx.Value(negotiatedKey).(string)
+	return t
+}
+
+// Negotiate checks the media types of a request against an endpoint before
+// calling next. A request with a body whose Content-Type isn't one of the
+// endpoint's RequestTypes gets a 415. The response type is the one of the
+// endpoint's ResponseTypes the Accept header prefers, or the first if
+// there's no Accept header, and is available to next from NegotiatedType.
+// A request accepting none of them gets a 406.
+func Negotiate(ep *Endpoint, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(ep.RequestTypes) > 0 && hasBody(r) {
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || !contains(ep.RequestTypes, mediaType) {
+				w.Header().Set("Accept", strings.Join(ep.RequestTypes, ", "))
+				http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+				return
+			}
+		}
+
+		if len(ep.ResponseTypes) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Accept")
+		t := acceptable(r.Header.Values("Accept"), ep.ResponseTypes)
+		if t == "" {
+			http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), negotiatedKey, t)))
+	})
+}
+
+func hasBody(r *http.Request) bool {
+	return r.ContentLength > 0 || len(r.TransferEncoding) > 0 || r.Header.Get("Content-Type") != ""
+}
+
+// acceptable returns the media type in offers that the Accept headers
+// prefer, breaking ties by the order of offers. It returns the first offer
+// if there are no Accept headers, and an empty string if none of the
+// offers is acceptable.
+func acceptable(accept []string, offers []string) string {
+	if len(accept) == 0 {
+		return offers[0]
+	}
+
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, header := range accept {
+		for _, part := range strings.Split(header, ",") {
+			mediaType, params, err := mime.ParseMediaType(part)
+			if err != nil {
+				continue
+			}
+			q := 1.0
+			if s, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(s, 64); err != nil {
+					continue
+				}
+			}
+			ranges = append(ranges, mediaRange{mediaType, q})
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		// The most specific range matching the offer sets its quality
+		q, specificity := 0.0, -1
+		for _, mr := range ranges {
+			s := -1
+			switch {
+			case mr.mediaType == offer:
+				s = 2
+			case strings.HasSuffix(mr.mediaType, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(mr.mediaType, "*")):
+				s = 1
+			case mr.mediaType == "*/*":
+				s = 0
+			}
+			if s > specificity {
+				q, specificity = mr.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,500 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/EconomistDigitalSolutions/ramlapi"
+	"github.com/buddhamagnet/raml"
+	yaml "github.com/buddhamagnet/yaml"
+)
+
+// FromRAML converts a RAML API definition to an OpenAPI 3 document, with
+// resource types and traits applied. XML schemas and OAuth 1.0 security
+// schemes have no OpenAPI equivalent and are left out.
+func FromRAML(api *raml.APIDefinition) (*Document, error) {
+	api = raml.Expanded(api)
+
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       api.Title,
+			Description: documentation(api.Documentation),
+			Version:     api.Version,
+		},
+		Paths:      make(map[string]*PathItem),
+		Components: &Components{},
+	}
+	if doc.Info.Version == "" {
+		doc.Info.Version = "1"
+	}
+	if api.BaseUri != "" {
+		doc.Servers = []Server{server(api)}
+	}
+
+	// Named JSON schemas become components referenced by bodies
+	refs := make(map[string]bool)
+	for _, named := range api.Schemas {
+		for name, schema := range named {
+			if s, ok := parseSchema(schema); ok {
+				refs[name] = true
+				if doc.Components.Schemas == nil {
+					doc.Components.Schemas = make(map[string]Schema)
+				}
+				doc.Components.Schemas[name] = s
+			}
+		}
+	}
+	for _, named := range api.SecuritySchemes {
+		for name, scheme := range named {
+			if s := securityScheme(&scheme); s != nil {
+				if doc.Components.SecuritySchemes == nil {
+					doc.Components.SecuritySchemes = make(map[string]*SecurityScheme)
+				}
+				doc.Components.SecuritySchemes[name] = s
+			}
+		}
+	}
+	if doc.Components.Schemas == nil && doc.Components.SecuritySchemes == nil {
+		doc.Components = nil
+	}
+	doc.Security = security(api.SecuredBy)
+
+	c := &converter{doc: doc, refs: refs, mediaType: api.MediaType, ids: make(map[string]bool)}
+	if c.mediaType == "" {
+		c.mediaType = "application/json"
+	}
+	for _, uri := range sortedKeys(api.Resources) {
+		resource := api.Resources[uri]
+		if err := c.resource(uri, &resource, nil, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+// JSON returns the document as indented JSON.
+func (d *Document) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// YAML returns the document as YAML.
+func (d *Document) YAML() ([]byte, error) {
+	return yaml.Marshal(d)
+}
+
+type converter struct {
+	doc       *Document
+	refs      map[string]bool
+	mediaType string
+	ids       map[string]bool
+}
+
+func (c *converter) resource(path string, r *raml.Resource, uriParams []*Parameter, securedBy []raml.DefinitionChoice) error {
+	uriParams = append([]*Parameter(nil), uriParams...)
+	for _, name := range sortedKeys(r.UriParameters) {
+		p := parameter(name, "path", r.UriParameters[name])
+		p.Required = true
+		uriParams = append(uriParams, p)
+	}
+	if len(r.SecuredBy) > 0 {
+		securedBy = r.SecuredBy
+	}
+
+	item := &PathItem{Summary: r.DisplayName, Description: r.Description}
+	for _, m := range r.Methods() {
+		op, err := c.operation(path, m, uriParams, securedBy)
+		if err != nil {
+			return err
+		}
+		switch m.Name {
+		case "GET":
+			item.Get = op
+		case "PUT":
+			item.Put = op
+		case "POST":
+			item.Post = op
+		case "DELETE":
+			item.Delete = op
+		case "HEAD":
+			item.Head = op
+		case "PATCH":
+			item.Patch = op
+		case "OPTIONS":
+			item.Options = op
+		case "TRACE":
+			item.Trace = op
+		}
+	}
+	if len(r.Methods()) > 0 {
+		c.doc.Paths[path] = item
+	}
+
+	for _, nested := range sortedKeys(r.Nested) {
+		if err := c.resource(path+nested, r.Nested[nested], uriParams, securedBy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *converter) operation(path string, m *raml.Method, uriParams []*Parameter, securedBy []raml.DefinitionChoice) (*Operation, error) {
+	// Operation IDs must be unique, display names needn't be
+	id := ramlapi.DefaultNamer(path, m)
+	if c.ids[id] {
+		id = ramlapi.VerbPathNamer(path, m)
+	}
+	c.ids[id] = true
+
+	op := &Operation{
+		OperationID: id,
+		Summary:     m.DisplayName,
+		Description: m.Description,
+		Responses:   make(map[string]*Response),
+	}
+
+	// Path parameters that aren't declared still need describing
+	declared := make(map[string]bool)
+	for _, p := range uriParams {
+		declared[p.Name] = true
+	}
+	op.Parameters = append(op.Parameters, uriParams...)
+	for _, segment := range strings.Split(path, "/") {
+		if name := strings.Trim(segment, "{}"); len(segment) > 2 && name != segment && !declared[name] {
+			op.Parameters = append(op.Parameters, &Parameter{Name: name, In: "path", Required: true, Schema: Schema{"type": "string"}})
+		}
+	}
+	for _, name := range sortedKeys(m.QueryParameters) {
+		op.Parameters = append(op.Parameters, parameter(name, "query", m.QueryParameters[name]))
+	}
+	for _, name := range sortedHeaders(m.Headers) {
+		op.Parameters = append(op.Parameters, parameter(string(name), "header", raml.NamedParameter(m.Headers[name])))
+	}
+
+	if content := c.content(m.Bodies); len(content) > 0 {
+		op.RequestBody = &RequestBody{Content: content}
+	}
+
+	for code, response := range m.Responses {
+		r := &Response{
+			Description: response.Description,
+			Content:     c.content(response.Bodies),
+		}
+		if r.Description == "" {
+			r.Description = http.StatusText(int(code))
+		}
+		for name, header := range response.Headers {
+			if r.Headers == nil {
+				r.Headers = make(map[string]*Header)
+			}
+			p := parameter(string(name), "header", raml.NamedParameter(header))
+			r.Headers[string(name)] = &Header{
+				Description: p.Description,
+				Required:    p.Required,
+				Schema:      p.Schema,
+				Example:     p.Example,
+			}
+		}
+		op.Responses[strconv.Itoa(int(code))] = r
+	}
+	if len(op.Responses) == 0 {
+		op.Responses["default"] = &Response{Description: "Default response"}
+	}
+
+	if len(m.SecuredBy) > 0 {
+		securedBy = m.SecuredBy
+	}
+	op.Security = security(securedBy)
+
+	return op, nil
+}
+
+// content converts RAML bodies to OpenAPI media types. Bodies without a
+// media type use the API's default media type.
+func (c *converter) content(bodies raml.Bodies) map[string]*MediaType {
+	forMIMEType := bodies.ForMIMEType
+	if len(forMIMEType) == 0 {
+		if bodies.DefaultSchema == "" && bodies.DefaultExample == "" && len(bodies.DefaultFormParameters) == 0 {
+			return nil
+		}
+		forMIMEType = map[string]raml.Body{c.mediaType: {
+			Schema:         bodies.DefaultSchema,
+			Example:        bodies.DefaultExample,
+			FormParameters: bodies.DefaultFormParameters,
+		}}
+	}
+	content := make(map[string]*MediaType)
+	for mediaType, body := range forMIMEType {
+		mt := &MediaType{}
+		switch {
+		case len(body.FormParameters) > 0:
+			properties := make(map[string]interface{})
+			var required []interface{}
+			for _, name := range sortedKeys(body.FormParameters) {
+				param := body.FormParameters[name]
+				properties[name] = map[string]interface{}(schema(param))
+				if param.Required {
+					required = append(required, name)
+				}
+			}
+			mt.Schema = Schema{"type": "object", "properties": properties}
+			if len(required) > 0 {
+				mt.Schema["required"] = required
+			}
+		case body.Schema != "":
+			if c.refs[body.Schema] {
+				mt.Schema = Schema{"$ref": "#/components/schemas/" + body.Schema}
+			} else if s, ok := parseSchema(body.Schema); ok {
+				mt.Schema = s
+			}
+		}
+		if body.Example != "" {
+			var example interface{}
+			if strings.Contains(mediaType, "json") && json.Unmarshal([]byte(body.Example), &example) == nil {
+				mt.Example = example
+			} else {
+				mt.Example = body.Example
+			}
+		}
+		content[mediaType] = mt
+	}
+	return content
+}
+
+// parameter converts a RAML named parameter.
+func parameter(name, in string, p raml.NamedParameter) *Parameter {
+	param := &Parameter{
+		Name:        name,
+		In:          in,
+		Description: p.Description,
+		Required:    p.Required,
+		Schema:      schema(p),
+	}
+	if p.Example != "" {
+		param.Example = p.Example
+	}
+	return param
+}
+
+// schema converts the constraints of a RAML named parameter.
+func schema(p raml.NamedParameter) Schema {
+	s := Schema{}
+	switch p.Type {
+	case "", "string", "date":
+		s["type"] = "string"
+	case "file":
+		s["type"] = "string"
+		s["format"] = "binary"
+	default:
+		s["type"] = p.Type
+	}
+	if len(p.Enum) > 0 {
+		s["enum"] = p.Enum
+	}
+	if p.Pattern != nil {
+		s["pattern"] = *p.Pattern
+	}
+	if p.MinLength != nil {
+		s["minLength"] = *p.MinLength
+	}
+	if p.MaxLength != nil {
+		s["maxLength"] = *p.MaxLength
+	}
+	if p.Minimum != nil {
+		s["minimum"] = *p.Minimum
+	}
+	if p.Maximum != nil {
+		s["maximum"] = *p.Maximum
+	}
+	if p.Default != nil {
+		s["default"] = p.Default
+	}
+	if p.Repeat != nil && *p.Repeat {
+		s = Schema{"type": "array", "items": map[string]interface{}(s)}
+	}
+	return s
+}
+
+// parseSchema converts a JSON schema. OpenAPI uses a subset of draft 4,
+// so draft 3 boolean required properties are moved to required lists and
+// keywords OpenAPI rejects are dropped.
+func parseSchema(schema string) (Schema, bool) {
+	var s map[string]interface{}
+	if err := json.Unmarshal([]byte(schema), &s); err != nil {
+		return nil, false
+	}
+	return Schema(convertSchema(s)), true
+}
+
+func convertSchema(s map[string]interface{}) map[string]interface{} {
+	delete(s, "$schema")
+	delete(s, "id")
+	if properties, ok := s["properties"].(map[string]interface{}); ok {
+		var required []interface{}
+		if list, ok := s["required"].([]interface{}); ok {
+			required = list
+		}
+		for _, name := range sortedKeys(properties) {
+			property, ok := properties[name].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if r, ok := property["required"].(bool); ok {
+				delete(property, "required")
+				if r {
+					required = append(required, name)
+				}
+			}
+			properties[name] = convertSchema(property)
+		}
+		if len(required) > 0 {
+			s["required"] = required
+		} else {
+			delete(s, "required")
+		}
+	}
+	if items, ok := s["items"].(map[string]interface{}); ok {
+		s["items"] = convertSchema(items)
+	}
+	return s
+}
+
+// server converts the baseUri and its parameters.
+func server(api *raml.APIDefinition) Server {
+	s := Server{URL: api.BaseUri}
+	for _, name := range templateNames(api.BaseUri) {
+		v := ServerVariable{}
+		if name == "version" {
+			v.Default = api.Version
+		}
+		if p, ok := api.BaseUriParameters[name]; ok {
+			v.Description = p.Description
+			if p.Default != nil {
+				v.Default = fmt.Sprint(p.Default)
+			}
+			for _, e := range p.Enum {
+				v.Enum = append(v.Enum, fmt.Sprint(e))
+			}
+			if v.Default == "" && len(v.Enum) > 0 {
+				v.Default = v.Enum[0]
+			}
+		}
+		if s.Variables == nil {
+			s.Variables = make(map[string]ServerVariable)
+		}
+		s.Variables[name] = v
+	}
+	return s
+}
+
+// securityScheme converts a RAML security scheme, returning nil if it has
+// no OpenAPI equivalent.
+func securityScheme(scheme *raml.SecurityScheme) *SecurityScheme {
+	s := &SecurityScheme{Description: scheme.Description}
+	switch scheme.Type {
+	case "OAuth 2.0":
+		s.Type = "oauth2"
+		scopes := make(map[string]string)
+		if list, ok := scheme.Settings["scopes"].([]interface{}); ok {
+			for _, scope := range list {
+				scopes[fmt.Sprint(scope)] = ""
+			}
+		}
+		authURL := fmt.Sprint(scheme.Settings["authorizationUri"])
+		tokenURL := fmt.Sprint(scheme.Settings["accessTokenUri"])
+		s.Flows = &OAuthFlows{}
+		grants, _ := scheme.Settings["authorizationGrants"].([]interface{})
+		for _, grant := range grants {
+			switch grant {
+			case "code":
+				s.Flows.AuthorizationCode = &OAuthFlow{AuthorizationURL: authURL, TokenURL: tokenURL, Scopes: scopes}
+			case "token":
+				s.Flows.Implicit = &OAuthFlow{AuthorizationURL: authURL, Scopes: scopes}
+			case "owner":
+				s.Flows.Password = &OAuthFlow{TokenURL: tokenURL, Scopes: scopes}
+			case "credentials":
+				s.Flows.ClientCredentials = &OAuthFlow{TokenURL: tokenURL, Scopes: scopes}
+			}
+		}
+	case "Basic Authentication":
+		s.Type = "http"
+		s.Scheme = "basic"
+	case "Digest Authentication":
+		s.Type = "http"
+		s.Scheme = "digest"
+	default:
+		if !strings.HasPrefix(scheme.Type, "x-") {
+			return nil
+		}
+		// Custom schemes are described by the headers or query
+		// parameters they use, which map onto API keys.
+		s.Type = "apiKey"
+		if headers := sortedHeaders(scheme.DescribedBy.Headers); len(headers) > 0 {
+			s.Name, s.In = string(headers[0]), "header"
+		} else if params := sortedKeys(scheme.DescribedBy.QueryParameters); len(params) > 0 {
+			s.Name, s.In = params[0], "query"
+		} else {
+			return nil
+		}
+	}
+	return s
+}
+
+// security converts securedBy. A null scheme makes security optional.
+func security(securedBy []raml.DefinitionChoice) []SecurityRequirement {
+	var requirements []SecurityRequirement
+	for _, s := range securedBy {
+		if s.Name == "" || s.Name == "null" {
+			requirements = append(requirements, SecurityRequirement{})
+			continue
+		}
+		scopes := []string{}
+		if list, ok := s.Parameters["scopes"]; ok && len(list.Values()) > 0 {
+			scopes = list.Values()
+		}
+		requirements = append(requirements, SecurityRequirement{s.Name: scopes})
+	}
+	return requirements
+}
+
+// documentation converts the documentation sections to Markdown.
+func documentation(docs []raml.Documentation) string {
+	var sections []string
+	for _, d := range docs {
+		sections = append(sections, strings.TrimSpace("## "+d.Title+"\n\n"+strings.TrimSpace(d.Content)))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+func templateNames(uri string) []string {
+	var names []string
+	for _, part := range strings.Split(uri, "{")[1:] {
+		if i := strings.Index(part, "}"); i != -1 {
+			names = append(names, part[:i])
+		}
+	}
+	return names
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedHeaders(headers map[raml.HTTPHeader]raml.Header) []raml.HTTPHeader {
+	names := make([]raml.HTTPHeader, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
@@ -0,0 +1,146 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/EconomistDigitalSolutions/ramlapi"
+	. "github.com/EconomistDigitalSolutions/ramlapi/openapi"
+)
+
+func fromRAML(t *testing.T, name string) *Document {
+	api, err := ramlapi.Process(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := FromRAML(api)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestFromRAML(t *testing.T) {
+	doc := fromRAML(t, "../fixtures/openapi.raml")
+
+	if doc.Info.Title != "Books" || doc.Info.Version != "v2" || doc.Info.Description != "## Overview\n\nA library of books." {
+		t.Errorf("unexpected info %+v", doc.Info)
+	}
+	if len(doc.Servers) != 1 || doc.Servers[0].Variables["version"].Default != "v2" || doc.Servers[0].Variables["region"].Default != "eu" {
+		t.Errorf("unexpected servers %+v", doc.Servers)
+	}
+
+	list := doc.Paths["/books"].Get
+	if list == nil {
+		t.Fatal("expected the resource type to add GET /books")
+	}
+	if list.Description != "Lists books." {
+		t.Errorf("expected resource type parameters substituted, got %q", list.Description)
+	}
+	if len(list.Parameters) != 1 || list.Parameters[0].Name != "page" || list.Parameters[0].Schema["minimum"] != 1.0 {
+		t.Errorf("expected the paged trait's query parameter, got %+v", list.Parameters)
+	}
+	if ref := list.Responses["200"].Content["application/json"].Schema["$ref"]; ref != "#/components/schemas/book" {
+		t.Errorf("expected schema reference, got %v", ref)
+	}
+
+	add := doc.Paths["/books"].Post
+	if add.OperationID != "AddBook" {
+		t.Errorf("expected operation AddBook, got %s", add.OperationID)
+	}
+	if example, ok := add.RequestBody.Content["application/json"].Example.(map[string]interface{}); !ok || example["title"] != "Ulysses" {
+		t.Errorf("expected parsed JSON example, got %#v", add.RequestBody.Content["application/json"].Example)
+	}
+	if r := add.Responses["201"]; r.Description != "Created" || !r.Headers["Location"].Required {
+		t.Errorf("unexpected response %+v", r)
+	}
+	if want := []SecurityRequirement{{"key": {}}, {}}; !reflect.DeepEqual(add.Security, want) {
+		t.Errorf("expected security %v, got %v", want, add.Security)
+	}
+
+	get := doc.Paths["/books/{isbn}"].Get
+	if len(get.Parameters) != 2 {
+		t.Fatalf("expected 2 parameters, got %d", len(get.Parameters))
+	}
+	if p := get.Parameters[0]; p.In != "path" || !p.Required || p.Schema["pattern"] != "^[0-9]{13}$" {
+		t.Errorf("unexpected path parameter %+v", p)
+	}
+	if p := get.Parameters[1]; p.In != "header" || p.Name != "Accept-Language" {
+		t.Errorf("unexpected header parameter %+v", p)
+	}
+
+	book := doc.Components.Schemas["book"]
+	if _, ok := book["$schema"]; ok {
+		t.Error("expected $schema to be removed")
+	}
+	if !reflect.DeepEqual(book["required"], []interface{}{"id"}) {
+		t.Errorf("expected draft 3 required converted, got %v", book["required"])
+	}
+
+	oauth := doc.Components.SecuritySchemes["oauth"]
+	if oauth.Type != "oauth2" || oauth.Flows.AuthorizationCode == nil || oauth.Flows.AuthorizationCode.TokenURL != "https://auth.example.com/token" {
+		t.Errorf("unexpected oauth scheme %+v", oauth)
+	}
+	if key := doc.Components.SecuritySchemes["key"]; key.Type != "apiKey" || key.In != "header" || key.Name != "X-Api-Key" {
+		t.Errorf("unexpected custom scheme %+v", key)
+	}
+	if want := []SecurityRequirement{{"oauth": {"read"}}}; !reflect.DeepEqual(doc.Security, want) {
+		t.Errorf("expected security %v, got %v", want, doc.Security)
+	}
+}
+
+func TestOperationIDs(t *testing.T) {
+	doc := fromRAML(t, "../fixtures/valid.raml")
+
+	seen := make(map[string]string)
+	for path, item := range doc.Paths {
+		for _, op := range []*Operation{item.Get, item.Put, item.Post, item.Delete, item.Head, item.Patch, item.Options, item.Trace} {
+			if op == nil {
+				continue
+			}
+			if other, ok := seen[op.OperationID]; ok {
+				t.Errorf("operation ID %s used by %s and %s", op.OperationID, other, path)
+			}
+			seen[op.OperationID] = path
+		}
+	}
+}
+
+func TestFormats(t *testing.T) {
+	doc := fromRAML(t, "../fixtures/openapi.raml")
+
+	b, err := doc.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("invalid JSON: %s", err)
+	}
+	if decoded["openapi"] != "3.0.3" {
+		t.Errorf("expected openapi 3.0.3, got %v", decoded["openapi"])
+	}
+
+	b, err = doc.YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b), "openapi: 3.0.3\n") || !strings.Contains(string(b), "$ref: '#/components/schemas/book'") {
+		t.Errorf("unexpected YAML:\n%s", b)
+	}
+}
+
+func TestFromRAMLLeavesSpec(t *testing.T) {
+	api, err := ramlapi.Process("../fixtures/openapi.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FromRAML(api); err != nil {
+		t.Fatal(err)
+	}
+	if books := api.Resources["/books"]; books.Get.Description != "" {
+		t.Errorf("expected the resource type not to be applied to the spec exported, got %q", books.Get.Description)
+	}
+}
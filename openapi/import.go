@@ -0,0 +1,768 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/buddhamagnet/raml"
+	yaml "github.com/buddhamagnet/yaml"
+)
+
+// ParseFile reads an OpenAPI 3 or Swagger 2 document, in YAML or JSON, and
+// converts it to a RAML API definition. The definition can be passed to
+// ramlapi.Build, or anything else that works with RAML, as usual.
+func ParseFile(name string) (*raml.APIDefinition, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(b)
+}
+
+// Parse converts an OpenAPI 3 or Swagger 2 document, in YAML or JSON, to a
+// RAML API definition. Each path becomes a top level resource, its
+// operations methods named after their operationId. Operations RAML 0.8
+// has no verb for, such as OPTIONS, and default responses are left out.
+// Security requirements needing several schemes at once are rejected, as
+// securedBy can only list alternatives.
+func Parse(b []byte) (*raml.APIDefinition, error) {
+	s := &spec{}
+	var err error
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && trimmed[0] == '{' {
+		err = json.Unmarshal(b, s)
+	} else {
+		err = yaml.Unmarshal(b, s)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed parsing OpenAPI document: %s", err)
+	}
+
+	switch {
+	case strings.HasPrefix(s.OpenAPI, "3."):
+	case s.Swagger == "2.0":
+	default:
+		return nil, errors.New("Failed parsing OpenAPI document: not an OpenAPI 3 or Swagger 2 document")
+	}
+	if err := s.checkSecurity(); err != nil {
+		return nil, err
+	}
+
+	return s.toRAML(), nil
+}
+
+// spec holds the parts of OpenAPI 3 and Swagger 2 documents that have a
+// RAML equivalent. Where the versions differ both forms are kept.
+type spec struct {
+	Swagger  string                   `json:"swagger" yaml:"swagger"`
+	OpenAPI  string                   `json:"openapi" yaml:"openapi"`
+	Info     Info                     `json:"info" yaml:"info"`
+	Servers  []specServer             `json:"servers" yaml:"servers"`
+	Paths    map[string]*specPath     `json:"paths" yaml:"paths"`
+	Security []map[string][]string    `json:"security" yaml:"security"`
+	Comps    specComponents           `json:"components" yaml:"components"`
+	Host     string                   `json:"host" yaml:"host"`
+	BasePath string                   `json:"basePath" yaml:"basePath"`
+	Schemes  []string                 `json:"schemes" yaml:"schemes"`
+	Consumes []string                 `json:"consumes" yaml:"consumes"`
+	Produces []string                 `json:"produces" yaml:"produces"`
+	Defs     map[string]interface{}   `json:"definitions" yaml:"definitions"`
+	Params   map[string]*specParam    `json:"parameters" yaml:"parameters"`
+	Resps    map[string]*specResp     `json:"responses" yaml:"responses"`
+	SecDefs  map[string]*specSecurity `json:"securityDefinitions" yaml:"securityDefinitions"`
+}
+
+type specServer struct {
+	URL       string                    `json:"url" yaml:"url"`
+	Variables map[string]ServerVariable `json:"variables" yaml:"variables"`
+}
+
+type specComponents struct {
+	Schemas         map[string]interface{}   `json:"schemas" yaml:"schemas"`
+	Parameters      map[string]*specParam    `json:"parameters" yaml:"parameters"`
+	Responses       map[string]*specResp     `json:"responses" yaml:"responses"`
+	RequestBodies   map[string]*specBody     `json:"requestBodies" yaml:"requestBodies"`
+	SecuritySchemes map[string]*specSecurity `json:"securitySchemes" yaml:"securitySchemes"`
+}
+
+type specPath struct {
+	Summary     string       `json:"summary" yaml:"summary"`
+	Description string       `json:"description" yaml:"description"`
+	Parameters  []*specParam `json:"parameters" yaml:"parameters"`
+	Get         *specOp      `json:"get" yaml:"get"`
+	Put         *specOp      `json:"put" yaml:"put"`
+	Post        *specOp      `json:"post" yaml:"post"`
+	Delete      *specOp      `json:"delete" yaml:"delete"`
+	Head        *specOp      `json:"head" yaml:"head"`
+	Patch       *specOp      `json:"patch" yaml:"patch"`
+	Options     *specOp      `json:"options" yaml:"options"`
+	Trace       *specOp      `json:"trace" yaml:"trace"`
+}
+
+type specOp struct {
+	OperationID string                 `json:"operationId" yaml:"operationId"`
+	Summary     string                 `json:"summary" yaml:"summary"`
+	Description string                 `json:"description" yaml:"description"`
+	Parameters  []*specParam           `json:"parameters" yaml:"parameters"`
+	RequestBody *specBody              `json:"requestBody" yaml:"requestBody"`
+	Responses   map[string]*specResp   `json:"responses" yaml:"responses"`
+	Security    *[]map[string][]string `json:"security" yaml:"security"`
+	Consumes    []string               `json:"consumes" yaml:"consumes"`
+	Produces    []string               `json:"produces" yaml:"produces"`
+}
+
+// specParam is a parameter or header. Swagger 2 puts the constraints on
+// the parameter itself, OpenAPI 3 in its schema.
+type specParam struct {
+	Ref         string                 `json:"$ref" yaml:"$ref"`
+	Name        string                 `json:"name" yaml:"name"`
+	In          string                 `json:"in" yaml:"in"`
+	Description string                 `json:"description" yaml:"description"`
+	Required    bool                   `json:"required" yaml:"required"`
+	Schema      map[string]interface{} `json:"schema" yaml:"schema"`
+	Type        string                 `json:"type" yaml:"type"`
+	Format      string                 `json:"format" yaml:"format"`
+	Items       map[string]interface{} `json:"items" yaml:"items"`
+	Enum        []raml.Any             `json:"enum" yaml:"enum"`
+	Pattern     string                 `json:"pattern" yaml:"pattern"`
+	MinLength   *int                   `json:"minLength" yaml:"minLength"`
+	MaxLength   *int                   `json:"maxLength" yaml:"maxLength"`
+	Minimum     *float64               `json:"minimum" yaml:"minimum"`
+	Maximum     *float64               `json:"maximum" yaml:"maximum"`
+	Default     interface{}            `json:"default" yaml:"default"`
+	Example     interface{}            `json:"example" yaml:"example"`
+}
+
+type specBody struct {
+	Ref         string                    `json:"$ref" yaml:"$ref"`
+	Description string                    `json:"description" yaml:"description"`
+	Content     map[string]*specMediaType `json:"content" yaml:"content"`
+}
+
+type specMediaType struct {
+	Schema   map[string]interface{}  `json:"schema" yaml:"schema"`
+	Example  interface{}             `json:"example" yaml:"example"`
+	Examples map[string]*specExample `json:"examples" yaml:"examples"`
+}
+
+type specExample struct {
+	Value interface{} `json:"value" yaml:"value"`
+}
+
+type specResp struct {
+	Ref         string                    `json:"$ref" yaml:"$ref"`
+	Description string                    `json:"description" yaml:"description"`
+	Headers     map[string]*specParam     `json:"headers" yaml:"headers"`
+	Content     map[string]*specMediaType `json:"content" yaml:"content"`
+	Schema      map[string]interface{}    `json:"schema" yaml:"schema"`
+	Examples    map[string]interface{}    `json:"examples" yaml:"examples"`
+}
+
+type specSecurity struct {
+	Type             string                    `json:"type" yaml:"type"`
+	Description      string                    `json:"description" yaml:"description"`
+	Name             string                    `json:"name" yaml:"name"`
+	In               string                    `json:"in" yaml:"in"`
+	Scheme           string                    `json:"scheme" yaml:"scheme"`
+	Flow             string                    `json:"flow" yaml:"flow"`
+	AuthorizationURL string                    `json:"authorizationUrl" yaml:"authorizationUrl"`
+	TokenURL         string                    `json:"tokenUrl" yaml:"tokenUrl"`
+	Scopes           map[string]string         `json:"scopes" yaml:"scopes"`
+	Flows            map[string]*specOAuthFlow `json:"flows" yaml:"flows"`
+}
+
+type specOAuthFlow struct {
+	AuthorizationURL string            `json:"authorizationUrl" yaml:"authorizationUrl"`
+	TokenURL         string            `json:"tokenUrl" yaml:"tokenUrl"`
+	Scopes           map[string]string `json:"scopes" yaml:"scopes"`
+}
+
+// Swagger 2 flow names and their RAML authorization grants.
+var grants = map[string]string{
+	"implicit":          "token",
+	"password":          "owner",
+	"application":       "credentials",
+	"clientCredentials": "credentials",
+	"accessCode":        "code",
+	"authorizationCode": "code",
+}
+
+// checkSecurity returns an error for the first security requirement that
+// needs more than one scheme.
+func (s *spec) checkSecurity() error {
+	check := func(where string, requirements []map[string][]string) error {
+		for _, requirement := range requirements {
+			if len(requirement) > 1 {
+				return fmt.Errorf("Failed converting OpenAPI document: %s requires %s together, which RAML can't express",
+					where, strings.Join(sortedKeys(requirement), " and "))
+			}
+		}
+		return nil
+	}
+
+	if err := check("the API", s.Security); err != nil {
+		return err
+	}
+	for _, path := range sortedKeys(s.Paths) {
+		item := s.Paths[path]
+		for _, op := range []struct {
+			name string
+			op   *specOp
+		}{
+			{"GET", item.Get}, {"HEAD", item.Head}, {"POST", item.Post}, {"PUT", item.Put},
+			{"DELETE", item.Delete}, {"PATCH", item.Patch}, {"OPTIONS", item.Options}, {"TRACE", item.Trace},
+		} {
+			if op.op == nil || op.op.Security == nil {
+				continue
+			}
+			if err := check(op.name+" "+path, *op.op.Security); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *spec) v2() bool {
+	return s.Swagger != ""
+}
+
+func (s *spec) toRAML() *raml.APIDefinition {
+	api := &raml.APIDefinition{
+		RAMLVersion: "#%RAML 0.8",
+		Title:       s.Info.Title,
+		Version:     s.Info.Version,
+		Resources:   make(map[string]raml.Resource),
+	}
+	if s.Info.Description != "" {
+		api.Documentation = []raml.Documentation{{Title: "Description", Content: s.Info.Description}}
+	}
+
+	if s.v2() {
+		if s.Host != "" {
+			scheme := "http"
+			if len(s.Schemes) > 0 {
+				scheme = s.Schemes[0]
+			}
+			api.BaseUri = scheme + "://" + s.Host + s.BasePath
+		} else {
+			api.BaseUri = s.BasePath
+		}
+		api.Protocols = upper(s.Schemes)
+	} else if len(s.Servers) > 0 {
+		api.BaseUri = s.Servers[0].URL
+		for _, name := range sortedKeys(s.Servers[0].Variables) {
+			if name == "version" {
+				continue
+			}
+			v := s.Servers[0].Variables[name]
+			param := raml.NamedParameter{Description: v.Description, Required: true}
+			if v.Default != "" {
+				param.Default = v.Default
+			}
+			for _, e := range v.Enum {
+				param.Enum = append(param.Enum, e)
+			}
+			if api.BaseUriParameters == nil {
+				api.BaseUriParameters = make(map[string]raml.NamedParameter)
+			}
+			api.BaseUriParameters[name] = param
+		}
+	}
+
+	schemas := s.Defs
+	if !s.v2() {
+		schemas = s.Comps.Schemas
+	}
+	for _, name := range sortedKeys(schemas) {
+		api.Schemas = append(api.Schemas, map[string]string{name: toJSON(schemas[name])})
+	}
+
+	secDefs := s.SecDefs
+	if !s.v2() {
+		secDefs = s.Comps.SecuritySchemes
+	}
+	for _, name := range sortedKeys(secDefs) {
+		if scheme := secDefs[name].toRAML(); scheme != nil {
+			api.SecuritySchemes = append(api.SecuritySchemes, map[string]raml.SecurityScheme{name: *scheme})
+		}
+	}
+	if s.Security != nil {
+		api.SecuredBy = securedBy(s.Security)
+	}
+
+	for path, item := range s.Paths {
+		api.Resources[path] = s.resource(item)
+	}
+
+	return api
+}
+
+func (s *spec) resource(item *specPath) raml.Resource {
+	r := raml.Resource{DisplayName: item.Summary, Description: item.Description}
+	for _, m := range []struct {
+		method **raml.Method
+		name   string
+		op     *specOp
+	}{
+		{&r.Get, "GET", item.Get},
+		{&r.Head, "HEAD", item.Head},
+		{&r.Post, "POST", item.Post},
+		{&r.Put, "PUT", item.Put},
+		{&r.Delete, "DELETE", item.Delete},
+		{&r.Patch, "PATCH", item.Patch},
+		{&r.Options, "OPTIONS", item.Options},
+		{&r.Trace, "TRACE", item.Trace},
+	} {
+		if m.op != nil {
+			*m.method = s.method(m.name, m.op, item.Parameters, &r)
+		}
+	}
+	return r
+}
+
+// method converts an operation. Path parameters are added to the resource
+// as RAML declares them there.
+func (s *spec) method(name string, op *specOp, pathParams []*specParam, r *raml.Resource) *raml.Method {
+	m := &raml.Method{
+		Name:        name,
+		DisplayName: op.OperationID,
+		Description: strings.TrimSpace(op.Summary + "\n\n" + op.Description),
+	}
+
+	// Operation parameters override path item parameters of the same name
+	params := make(map[string]*specParam)
+	var order []string
+	for _, p := range append(append([]*specParam(nil), pathParams...), op.Parameters...) {
+		p = s.param(p)
+		if p == nil {
+			continue
+		}
+		key := p.In + " " + p.Name
+		if _, ok := params[key]; !ok {
+			order = append(order, key)
+		}
+		params[key] = p
+	}
+
+	form := make(map[string]raml.NamedParameter)
+	var body *specParam
+	for _, key := range order {
+		p := params[key]
+		switch p.In {
+		case "path":
+			if r.UriParameters == nil {
+				r.UriParameters = make(map[string]raml.NamedParameter)
+			}
+			np := p.namedParameter()
+			np.Required = true
+			r.UriParameters[p.Name] = np
+		case "query":
+			if m.QueryParameters == nil {
+				m.QueryParameters = make(map[string]raml.NamedParameter)
+			}
+			m.QueryParameters[p.Name] = p.namedParameter()
+		case "header":
+			if m.Headers == nil {
+				m.Headers = make(map[raml.HTTPHeader]raml.Header)
+			}
+			m.Headers[raml.HTTPHeader(p.Name)] = raml.Header(p.namedParameter())
+		case "formData":
+			form[p.Name] = p.namedParameter()
+		case "body":
+			body = p
+		}
+	}
+
+	if s.v2() {
+		consumes := op.Consumes
+		if consumes == nil {
+			consumes = s.Consumes
+		}
+		switch {
+		case len(form) > 0:
+			mediaType := "application/x-www-form-urlencoded"
+			for _, p := range form {
+				if p.Type == "file" {
+					mediaType = "multipart/form-data"
+				}
+			}
+			m.Bodies.ForMIMEType = map[string]raml.Body{mediaType: {FormParameters: form}}
+		case body != nil:
+			if len(consumes) == 0 {
+				consumes = []string{"application/json"}
+			}
+			m.Bodies.ForMIMEType = make(map[string]raml.Body)
+			for _, mediaType := range consumes {
+				m.Bodies.ForMIMEType[mediaType] = raml.Body{
+					Description: body.Description,
+					Schema:      s.schema(body.Schema),
+					Example:     example(body.Example),
+				}
+			}
+		}
+	} else if rb := s.requestBody(op.RequestBody); rb != nil {
+		m.Bodies = s.bodies(rb.Content)
+	}
+
+	for code, resp := range op.Responses {
+		status, err := strconv.Atoi(code)
+		if err != nil {
+			continue
+		}
+		resp = s.response(resp)
+		if resp == nil {
+			continue
+		}
+		if m.Responses == nil {
+			m.Responses = make(map[raml.HTTPCode]raml.Response)
+		}
+		m.Responses[raml.HTTPCode(status)] = s.toResponse(op, resp)
+	}
+
+	if op.Security != nil {
+		m.SecuredBy = securedBy(*op.Security)
+	}
+
+	return m
+}
+
+func (s *spec) toResponse(op *specOp, resp *specResp) raml.Response {
+	r := raml.Response{Description: resp.Description}
+	for _, name := range sortedKeys(resp.Headers) {
+		if r.Headers == nil {
+			r.Headers = make(map[raml.HTTPHeader]raml.Header)
+		}
+		r.Headers[raml.HTTPHeader(name)] = raml.Header(resp.Headers[name].namedParameter())
+	}
+	if !s.v2() {
+		r.Bodies = s.bodies(resp.Content)
+		return r
+	}
+
+	if resp.Schema == nil && resp.Examples == nil {
+		return r
+	}
+	produces := op.Produces
+	if produces == nil {
+		produces = s.Produces
+	}
+	if len(produces) == 0 {
+		produces = []string{"application/json"}
+	}
+	r.Bodies.ForMIMEType = make(map[string]raml.Body)
+	for _, mediaType := range produces {
+		r.Bodies.ForMIMEType[mediaType] = raml.Body{
+			Schema:  s.schema(resp.Schema),
+			Example: example(resp.Examples[mediaType]),
+		}
+	}
+	return r
+}
+
+// bodies converts OpenAPI 3 content. Object schemas of form media types
+// become form parameters.
+func (s *spec) bodies(content map[string]*specMediaType) raml.Bodies {
+	var bodies raml.Bodies
+	for mediaType, mt := range content {
+		if bodies.ForMIMEType == nil {
+			bodies.ForMIMEType = make(map[string]raml.Body)
+		}
+		body := raml.Body{Example: example(mt.Example)}
+		if body.Example == "" {
+			for _, name := range sortedKeys(mt.Examples) {
+				if mt.Examples[name] != nil {
+					body.Example = example(mt.Examples[name].Value)
+					break
+				}
+			}
+		}
+		if mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data" {
+			body.FormParameters = s.formParameters(mt.Schema)
+		} else {
+			body.Schema = s.schema(mt.Schema)
+		}
+		bodies.ForMIMEType[mediaType] = body
+	}
+	return bodies
+}
+
+func (s *spec) formParameters(schema map[string]interface{}) map[string]raml.NamedParameter {
+	schema = s.resolveSchema(schema)
+	properties, _ := normalize(schema["properties"]).(map[string]interface{})
+	required := make(map[string]bool)
+	if list, ok := normalize(schema["required"]).([]interface{}); ok {
+		for _, name := range list {
+			required[fmt.Sprint(name)] = true
+		}
+	}
+	params := make(map[string]raml.NamedParameter)
+	for name, property := range properties {
+		property, _ := property.(map[string]interface{})
+		p := &specParam{Name: name, Schema: property, Required: required[name]}
+		params[name] = p.namedParameter()
+	}
+	return params
+}
+
+// schema returns a reference to a named schema by its name, as RAML does,
+// and anything else as a JSON schema.
+func (s *spec) schema(schema map[string]interface{}) string {
+	if schema == nil {
+		return ""
+	}
+	if name, ok := refName(schema["$ref"]); ok {
+		return name
+	}
+	return toJSON(schema)
+}
+
+// resolveSchema follows a reference to a named schema.
+func (s *spec) resolveSchema(schema map[string]interface{}) map[string]interface{} {
+	name, ok := refName(schema["$ref"])
+	if !ok {
+		return schema
+	}
+	named := s.Defs
+	if !s.v2() {
+		named = s.Comps.Schemas
+	}
+	resolved, _ := normalize(named[name]).(map[string]interface{})
+	return resolved
+}
+
+func (s *spec) param(p *specParam) *specParam {
+	if p == nil || p.Ref == "" {
+		return p
+	}
+	if s.v2() {
+		return s.Params[strings.TrimPrefix(p.Ref, "#/parameters/")]
+	}
+	return s.Comps.Parameters[strings.TrimPrefix(p.Ref, "#/components/parameters/")]
+}
+
+func (s *spec) response(r *specResp) *specResp {
+	if r == nil || r.Ref == "" {
+		return r
+	}
+	if s.v2() {
+		return s.Resps[strings.TrimPrefix(r.Ref, "#/responses/")]
+	}
+	return s.Comps.Responses[strings.TrimPrefix(r.Ref, "#/components/responses/")]
+}
+
+func (s *spec) requestBody(b *specBody) *specBody {
+	if b == nil || b.Ref == "" {
+		return b
+	}
+	return s.Comps.RequestBodies[strings.TrimPrefix(b.Ref, "#/components/requestBodies/")]
+}
+
+// namedParameter converts the constraints of a parameter, taking them from
+// its schema if it has one.
+func (p *specParam) namedParameter() raml.NamedParameter {
+	c := p
+	if p.Schema != nil {
+		c = &specParam{}
+		b, _ := json.Marshal(normalize(p.Schema))
+		json.Unmarshal(b, c)
+	}
+	np := raml.NamedParameter{
+		Description: p.Description,
+		Required:    p.Required,
+		Type:        ramlType(c.Type, c.Format),
+		Enum:        c.Enum,
+		MinLength:   c.MinLength,
+		MaxLength:   c.MaxLength,
+		Minimum:     c.Minimum,
+		Maximum:     c.Maximum,
+		Default:     normalize(c.Default),
+	}
+	if c.Type == "array" {
+		items := &specParam{}
+		b, _ := json.Marshal(normalize(c.Items))
+		json.Unmarshal(b, items)
+		repeat := true
+		np.Repeat = &repeat
+		np.Type = ramlType(items.Type, items.Format)
+		np.Enum = items.Enum
+	}
+	if c.Pattern != "" {
+		pattern := c.Pattern
+		np.Pattern = &pattern
+	}
+	if p.Example != nil {
+		np.Example = fmt.Sprint(p.Example)
+	} else if c.Example != nil {
+		np.Example = fmt.Sprint(c.Example)
+	}
+	return np
+}
+
+func ramlType(typ, format string) string {
+	switch {
+	case typ == "integer", typ == "number", typ == "boolean", typ == "file":
+		return typ
+	case typ == "string" && format == "binary":
+		return "file"
+	case typ == "string" && (format == "date" || format == "date-time"):
+		return "date"
+	}
+	return "string"
+}
+
+// toRAML converts a security scheme. Schemes without a RAML type become
+// custom x- schemes described by the header or query parameter they use.
+func (sec *specSecurity) toRAML() *raml.SecurityScheme {
+	scheme := &raml.SecurityScheme{Description: sec.Description}
+	switch {
+	case sec.Type == "oauth2":
+		scheme.Type = "OAuth 2.0"
+		scheme.Settings = make(map[string]raml.Any)
+		flows := sec.Flows
+		if flows == nil {
+			flows = map[string]*specOAuthFlow{sec.Flow: {sec.AuthorizationURL, sec.TokenURL, sec.Scopes}}
+		}
+		var grantList, scopes []raml.Any
+		seen := make(map[string]bool)
+		for _, name := range sortedKeys(flows) {
+			flow := flows[name]
+			if grant, ok := grants[name]; ok {
+				grantList = append(grantList, grant)
+			}
+			if flow.AuthorizationURL != "" {
+				scheme.Settings["authorizationUri"] = flow.AuthorizationURL
+			}
+			if flow.TokenURL != "" {
+				scheme.Settings["accessTokenUri"] = flow.TokenURL
+			}
+			for _, scope := range sortedKeys(flow.Scopes) {
+				if !seen[scope] {
+					seen[scope] = true
+					scopes = append(scopes, scope)
+				}
+			}
+		}
+		scheme.Settings["authorizationGrants"] = grantList
+		scheme.Settings["scopes"] = scopes
+	case sec.Type == "basic", sec.Type == "http" && strings.EqualFold(sec.Scheme, "basic"):
+		scheme.Type = "Basic Authentication"
+	case sec.Type == "http" && strings.EqualFold(sec.Scheme, "digest"):
+		scheme.Type = "Digest Authentication"
+	case sec.Type == "http":
+		scheme.Type = "x-" + strings.ToLower(sec.Scheme)
+		scheme.DescribedBy.Headers = map[raml.HTTPHeader]raml.Header{"Authorization": {Type: "string", Required: true}}
+	case sec.Type == "apiKey":
+		scheme.Type = "x-api-key"
+		param := raml.NamedParameter{Type: "string", Required: true}
+		switch sec.In {
+		case "header":
+			scheme.DescribedBy.Headers = map[raml.HTTPHeader]raml.Header{raml.HTTPHeader(sec.Name): raml.Header(param)}
+		case "query":
+			scheme.DescribedBy.QueryParameters = map[string]raml.NamedParameter{sec.Name: param}
+		default:
+			return nil
+		}
+	default:
+		return nil
+	}
+	return scheme
+}
+
+// securedBy converts security requirements, which checkSecurity has made
+// sure need one scheme at most. An empty requirement makes security
+// optional, and an empty list of them turns it off, which RAML spells null
+// in both cases.
+func securedBy(requirements []map[string][]string) []raml.DefinitionChoice {
+	if len(requirements) == 0 {
+		return []raml.DefinitionChoice{{Name: "null"}}
+	}
+	choices := []raml.DefinitionChoice{}
+	for _, requirement := range requirements {
+		if len(requirement) == 0 {
+			choices = append(choices, raml.DefinitionChoice{Name: "null"})
+		}
+		for _, name := range sortedKeys(requirement) {
+			choice := raml.DefinitionChoice{Name: name}
+			if scopes := requirement[name]; len(scopes) > 0 {
+				choice.Parameters = raml.DefinitionParameters{"scopes": {List: scopes}}
+			}
+			choices = append(choices, choice)
+		}
+	}
+	return choices
+}
+
+// refName returns the name of the schema referred to by ref.
+func refName(ref interface{}) (string, bool) {
+	s, ok := ref.(string)
+	if !ok {
+		return "", false
+	}
+	for _, prefix := range []string{"#/definitions/", "#/components/schemas/"} {
+		if strings.HasPrefix(s, prefix) {
+			return s[len(prefix):], true
+		}
+	}
+	return "", false
+}
+
+// example converts an example value to the string RAML expects, as JSON
+// unless it is a string already.
+func example(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	return toJSON(v)
+}
+
+func toJSON(v interface{}) string {
+	b, err := json.MarshalIndent(normalize(v), "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// normalize converts the maps YAML decodes to the string keyed maps JSON
+// uses.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = normalize(e)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = normalize(e)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = normalize(e)
+		}
+		return s
+	}
+	return v
+}
+
+func upper(list []string) []string {
+	var upper []string
+	for _, s := range list {
+		upper = append(upper, strings.ToUpper(s))
+	}
+	sort.Strings(upper)
+	return upper
+}
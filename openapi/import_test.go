@@ -0,0 +1,174 @@
+package openapi_test
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/EconomistDigitalSolutions/ramlapi"
+	. "github.com/EconomistDigitalSolutions/ramlapi/openapi"
+	"github.com/buddhamagnet/raml"
+)
+
+// routes lists the endpoints Build finds in api.
+func routes(t *testing.T, api *raml.APIDefinition) []string {
+	var routes []string
+	err := ramlapi.Build(api, func(ep *ramlapi.Endpoint) {
+		routes = append(routes, ep.Verb+" "+ep.Path+" "+ep.Handler)
+	}, ramlapi.Quiet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(routes)
+	return routes
+}
+
+func TestParseSwagger(t *testing.T) {
+	api, err := ParseFile("../fixtures/swagger.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ramlapi.Validate(api); err != nil {
+		t.Errorf("expected a valid definition, got %s", err)
+	}
+
+	want := []string{
+		"GET /pets ListPets",
+		"GET /pets/{petId} ShowPetById",
+		"POST /pets CreatePet",
+		"PUT /pets/{petId} UpdatePet",
+	}
+	if got := routes(t, api); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected routes %v, got %v", want, got)
+	}
+
+	if api.BaseUri != "https://pets.example.com/v1" {
+		t.Errorf("unexpected baseUri %s", api.BaseUri)
+	}
+
+	pets := api.Resources["/pets"]
+	if tags := pets.Get.QueryParameters["tags"]; tags.Repeat == nil || !*tags.Repeat {
+		t.Errorf("expected array parameter to repeat, got %+v", tags)
+	}
+	if limit := pets.Get.QueryParameters["limit"]; limit.Type != "integer" || *limit.Maximum != 100 || limit.Default != 20.0 {
+		t.Errorf("unexpected limit parameter %+v", limit)
+	}
+	if body := pets.Post.Bodies.ForMIMEType["application/json"]; body.Schema != "Pet" {
+		t.Errorf("expected body to use the Pet schema, got %q", body.Schema)
+	}
+	if want := []raml.DefinitionChoice{{Name: "key"}, {Name: "null"}}; !reflect.DeepEqual(pets.Post.SecuredBy, want) {
+		t.Errorf("expected securedBy %v, got %v", want, pets.Post.SecuredBy)
+	}
+	if _, ok := pets.Get.Responses[200]; !ok || len(pets.Get.Responses) != 1 {
+		t.Errorf("expected only the 200 response, got %v", pets.Get.Responses)
+	}
+
+	pet := api.Resources["/pets/{petId}"]
+	if id := pet.UriParameters["petId"]; id.Type != "integer" || !id.Required {
+		t.Errorf("expected referenced path parameter, got %+v", id)
+	}
+	if h := pet.Get.Headers["X-Request-Id"]; h.Pattern == nil || *h.Pattern != "^[a-f0-9]+$" {
+		t.Errorf("unexpected header %+v", h)
+	}
+	form := pet.Put.Bodies.ForMIMEType["multipart/form-data"].FormParameters
+	if !form["name"].Required || form["photo"].Type != "file" {
+		t.Errorf("unexpected form parameters %+v", form)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	original, err := ramlapi.Process("../fixtures/openapi.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := FromRAML(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, marshal := range map[string]func() ([]byte, error){"json": doc.JSON, "yaml": doc.YAML} {
+		b, err := marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		api, err := Parse(b)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if got, want := routes(t, api), routes(t, original); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected routes %v, got %v", name, want, got)
+		}
+		if isbn := api.Resources["/books/{isbn}"].UriParameters["isbn"]; isbn.Pattern == nil || *isbn.Pattern != "^[0-9]{13}$" {
+			t.Errorf("%s: unexpected isbn parameter %+v", name, isbn)
+		}
+		if scheme := api.SecuritySchemes[1]["oauth"]; scheme.Type != "OAuth 2.0" || scheme.Settings["accessTokenUri"] != "https://auth.example.com/token" {
+			t.Errorf("%s: unexpected oauth scheme %+v", name, scheme)
+		}
+		if want := []raml.DefinitionChoice{{Name: "oauth", Parameters: raml.DefinitionParameters{"scopes": {List: []string{"read"}}}}}; !reflect.DeepEqual(api.SecuredBy, want) {
+			t.Errorf("%s: expected securedBy %v, got %v", name, want, api.SecuredBy)
+		}
+	}
+}
+
+func TestSecurityRequirements(t *testing.T) {
+	api, err := Parse([]byte(`openapi: 3.0.0
+info: {title: Status, version: "1"}
+components:
+  securitySchemes:
+    basic: {type: http, scheme: basic}
+security:
+  - basic: []
+paths:
+  /health:
+    get:
+      operationId: Health
+      security: []
+      responses: {"200": {description: OK}}
+  /status:
+    get:
+      operationId: Status
+      responses: {"200": {description: OK}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	securedBy := make(map[string]string)
+	err = ramlapi.Build(api, func(ep *ramlapi.Endpoint) {
+		var names []string
+		for _, s := range ep.SecuredBy {
+			names = append(names, s.Name)
+		}
+		securedBy[ep.Path] = strings.Join(names, " ")
+	}, ramlapi.Quiet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An empty list of requirements makes the operation public
+	if want := map[string]string{"/health": "null", "/status": "basic"}; !reflect.DeepEqual(securedBy, want) {
+		t.Errorf("expected securedBy %v, got %v", want, securedBy)
+	}
+
+	// Requirements needing several schemes can't be expressed in RAML
+	_, err = Parse([]byte(`openapi: 3.0.0
+info: {title: Status, version: "1"}
+paths:
+  /admin:
+    post:
+      security:
+        - basic: []
+          key: []
+      responses: {"200": {description: OK}}
+`))
+	if want := "Failed converting OpenAPI document: POST /admin requires basic and key together, which RAML can't express"; err == nil || err.Error() != want {
+		t.Errorf("expected error %q, got %v", want, err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, doc := range []string{"title: not a spec\n", "{not json", "openapi: [\n"} {
+		if _, err := Parse([]byte(doc)); err == nil {
+			t.Errorf("expected an error parsing %q", doc)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Package openapi converts between RAML API definitions and OpenAPI
+// documents.
+package openapi
+
+// Document is an OpenAPI 3 document.
+type Document struct {
+	OpenAPI    string                `json:"openapi" yaml:"openapi"`
+	Info       Info                  `json:"info" yaml:"info"`
+	Servers    []Server              `json:"servers,omitempty" yaml:"servers,omitempty"`
+	Paths      map[string]*PathItem  `json:"paths" yaml:"paths"`
+	Components *Components           `json:"components,omitempty" yaml:"components,omitempty"`
+	Security   []SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title" yaml:"title"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Version     string `json:"version" yaml:"version"`
+}
+
+// Server is a base URL for the API.
+type Server struct {
+	URL       string                    `json:"url" yaml:"url"`
+	Variables map[string]ServerVariable `json:"variables,omitempty" yaml:"variables,omitempty"`
+}
+
+// ServerVariable is a placeholder in a server URL.
+type ServerVariable struct {
+	Default     string   `json:"default" yaml:"default"`
+	Enum        []string `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// PathItem holds the operations on a path.
+type PathItem struct {
+	Summary     string     `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string     `json:"description,omitempty" yaml:"description,omitempty"`
+	Get         *Operation `json:"get,omitempty" yaml:"get,omitempty"`
+	Put         *Operation `json:"put,omitempty" yaml:"put,omitempty"`
+	Post        *Operation `json:"post,omitempty" yaml:"post,omitempty"`
+	Delete      *Operation `json:"delete,omitempty" yaml:"delete,omitempty"`
+	Head        *Operation `json:"head,omitempty" yaml:"head,omitempty"`
+	Patch       *Operation `json:"patch,omitempty" yaml:"patch,omitempty"`
+	Options     *Operation `json:"options,omitempty" yaml:"options,omitempty"`
+	Trace       *Operation `json:"trace,omitempty" yaml:"trace,omitempty"`
+}
+
+// Operation is a single API operation on a path.
+type Operation struct {
+	OperationID string                `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Summary     string                `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string                `json:"description,omitempty" yaml:"description,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses" yaml:"responses"`
+	Security    []SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
+}
+
+// Parameter is a path, query or header parameter.
+type Parameter struct {
+	Name        string      `json:"name" yaml:"name"`
+	In          string      `json:"in" yaml:"in"`
+	Description string      `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool        `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema      Schema      `json:"schema,omitempty" yaml:"schema,omitempty"`
+	Example     interface{} `json:"example,omitempty" yaml:"example,omitempty"`
+}
+
+// RequestBody describes the bodies a request may have.
+type RequestBody struct {
+	Description string                `json:"description,omitempty" yaml:"description,omitempty"`
+	Content     map[string]*MediaType `json:"content" yaml:"content"`
+}
+
+// Response describes a response to an operation.
+type Response struct {
+	Description string                `json:"description" yaml:"description"`
+	Headers     map[string]*Header    `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+// Header is a response header.
+type Header struct {
+	Description string      `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool        `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema      Schema      `json:"schema,omitempty" yaml:"schema,omitempty"`
+	Example     interface{} `json:"example,omitempty" yaml:"example,omitempty"`
+}
+
+// MediaType is the schema and example of a body.
+type MediaType struct {
+	Schema  Schema      `json:"schema,omitempty" yaml:"schema,omitempty"`
+	Example interface{} `json:"example,omitempty" yaml:"example,omitempty"`
+}
+
+// Schema is a JSON schema object.
+type Schema map[string]interface{}
+
+// Components holds reusable schemas and security schemes.
+type Components struct {
+	Schemas         map[string]Schema          `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty" yaml:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes how the API is secured.
+type SecurityScheme struct {
+	Type        string      `json:"type" yaml:"type"`
+	Description string      `json:"description,omitempty" yaml:"description,omitempty"`
+	Scheme      string      `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+	Name        string      `json:"name,omitempty" yaml:"name,omitempty"`
+	In          string      `json:"in,omitempty" yaml:"in,omitempty"`
+	Flows       *OAuthFlows `json:"flows,omitempty" yaml:"flows,omitempty"`
+}
+
+// OAuthFlows lists the OAuth 2.0 flows a security scheme supports.
+type OAuthFlows struct {
+	Implicit          *OAuthFlow `json:"implicit,omitempty" yaml:"implicit,omitempty"`
+	Password          *OAuthFlow `json:"password,omitempty" yaml:"password,omitempty"`
+	ClientCredentials *OAuthFlow `json:"clientCredentials,omitempty" yaml:"clientCredentials,omitempty"`
+	AuthorizationCode *OAuthFlow `json:"authorizationCode,omitempty" yaml:"authorizationCode,omitempty"`
+}
+
+// OAuthFlow is a single OAuth 2.0 flow.
+type OAuthFlow struct {
+	AuthorizationURL string            `json:"authorizationUrl,omitempty" yaml:"authorizationUrl,omitempty"`
+	TokenURL         string            `json:"tokenUrl,omitempty" yaml:"tokenUrl,omitempty"`
+	Scopes           map[string]string `json:"scopes" yaml:"scopes"`
+}
+
+// SecurityRequirement maps security scheme names to required scopes.
+type SecurityRequirement map[string][]string
@@ -0,0 +1,108 @@
+package ramlapi
+
+import (
+	"log"
+	"log/slog"
+	"strings"
+
+	"github.com/buddhamagnet/raml"
+)
+
+// Logger is the logging interface used by Build. It is satisfied
+// by *log.Logger.
+type Logger interface {
+	Println(v ...interface{})
+}
+
+// Option configures Build.
+type Option func(*options)
+
+type options struct {
+	logger   Logger
+	slogger  *slog.Logger
+	namer    Namer
+	basePath string
+	verbs    map[string]bool
+
+	baseURI       bool
+	baseURIValues map[string]string
+
+	// mediaType, protocols and securitySchemes are the API's defaults
+	// and its schemes by name, set by Build.
+	mediaType       string
+	protocols       []string
+	securitySchemes map[string]*raml.SecurityScheme
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		logger: log.Default(),
+		namer:  DefaultNamer,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithLogger sends Build's progress messages to l.
+func WithLogger(l Logger) Option {
+	return func(o *options) {
+		o.logger = l
+		o.slogger = nil
+	}
+}
+
+// WithSlog sends Build's progress messages to l as structured records.
+func WithSlog(l *slog.Logger) Option {
+	return func(o *options) {
+		o.slogger = l
+		o.logger = nil
+	}
+}
+
+// Quiet stops Build logging anything.
+func Quiet() Option {
+	return func(o *options) {
+		o.logger = nil
+		o.slogger = nil
+	}
+}
+
+// WithNamer names handlers with n instead of DefaultNamer.
+func WithNamer(n Namer) Option {
+	return func(o *options) {
+		o.namer = n
+	}
+}
+
+// WithBasePath prefixes every endpoint path with prefix. Handlers are
+// named as they would be without it.
+func WithBasePath(prefix string) Option {
+	return func(o *options) {
+		o.basePath = strings.TrimSuffix(prefix, "/")
+	}
+}
+
+// WithVerbs limits Build to endpoints using the given HTTP verbs.
+func WithVerbs(verbs ...string) Option {
+	return func(o *options) {
+		o.verbs = make(map[string]bool)
+		for _, v := range verbs {
+			o.verbs[strings.ToUpper(v)] = true
+		}
+	}
+}
+
+func (o *options) logEndpoint(ep *Endpoint) {
+	switch {
+	case o.slogger != nil:
+		o.slogger.Info("processing", "verb", ep.Verb, "handler", ep.Handler, "path", ep.Path)
+	case o.logger != nil:
+		o.logger.Println("processing", ep)
+	}
+}
+
+func (o *options) wants(verb string) bool {
+	return o.verbs == nil || o.verbs[verb]
+}
@@ -0,0 +1,70 @@
+package ramlapi
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/buddhamagnet/raml"
+)
+
+// apiProtocols returns the protocols of an API, taken from the scheme of
+// its baseUri if it doesn't declare any.
+func apiProtocols(api *raml.APIDefinition) []string {
+	if len(api.Protocols) > 0 {
+		return upper(api.Protocols)
+	}
+	if u, err := url.Parse(api.BaseUri); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return []string{strings.ToUpper(u.Scheme)}
+	}
+	return nil
+}
+
+func upper(list []string) []string {
+	u := make([]string, len(list))
+	for i, s := range list {
+		u[i] = strings.ToUpper(s)
+	}
+	return u
+}
+
+// RequireHTTPS stops plain HTTP requests reaching next when an endpoint may
+// only be called over HTTPS. A request counts as HTTPS if it arrived over
+// TLS, or, when trustProxy is true, if X-Forwarded-Proto says so; only set
+// it behind a proxy that overwrites the header. Plain requests are
+// redirected to the same path on host over HTTPS, typically the host of the
+// baseUri, and rejected with a 403 if host is empty. The request's own Host
+// is never used, so the redirect can't be pointed elsewhere. Endpoints
+// allowing HTTP are left alone.
+func RequireHTTPS(ep *Endpoint, next http.Handler, host string, trustProxy bool) http.Handler {
+	if len(ep.Protocols) == 0 || contains(ep.Protocols, "HTTP") {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isHTTPS(r, trustProxy) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if host == "" {
+			http.Error(w, "HTTPS required", http.StatusForbidden)
+			return
+		}
+		// 308 keeps the method and body of non-idempotent requests
+		code := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			code = http.StatusMovedPermanently
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), code)
+	})
+}
+
+func isHTTPS(r *http.Request, trustProxy bool) bool {
+	if r.TLS != nil {
+		return true
+	}
+	if !trustProxy {
+		return false
+	}
+	proto, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Proto"), ",")
+	return strings.EqualFold(strings.TrimSpace(proto), "https")
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/EconomistDigitalSolutions/ramlapi"
+	"github.com/EconomistDigitalSolutions/ramlapi/openapi"
+)
+
+var (
+	ramlFile string
+	format   string
+)
+
+func init() {
+	flag.StringVar(&ramlFile, "ramlfile", "api.raml", "RAML file to convert")
+	flag.StringVar(&format, "format", "yaml", "Output format: yaml or json")
+}
+
+func main() {
+	flag.Parse()
+	if format != "yaml" && format != "json" {
+		log.Fatalf("unknown format %q", format)
+	}
+
+	api, err := ramlapi.Process(ramlFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	doc, err := openapi.FromRAML(api)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var out []byte
+	if format == "json" {
+		out, err = doc.JSON()
+	} else {
+		out, err = doc.YAML()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	os.Stdout.Write(out)
+}
@@ -1,9 +1,9 @@
 package ramlapi
 
 import (
-	"errors"
 	"fmt"
-	"log"
+	"io"
+	"io/fs"
 	"regexp"
 	"strings"
 
@@ -16,22 +16,47 @@ func init() {
 	vizer = regexp.MustCompile("[^A-Za-z0-9]+")
 }
 
-// Parameter is a path or query string parameter.
+// Parameter is a path, query string, header or form parameter.
 type Parameter struct {
-	Key      string
-	Type     string
-	Pattern  string
-	Required bool
+	Key      string `json:"key"`
+	Type     string `json:"type,omitempty"`
+	Pattern  string `json:"pattern,omitempty"`
+	Required bool   `json:"required"`
+
+	// Definition is the parameter as declared in the RAML, with all of
+	// its constraints.
+	Definition raml.NamedParameter `json:"-"`
 }
 
 // Endpoint describes an API endpoint.
 type Endpoint struct {
-	Verb            string
-	Handler         string
-	Path            string
-	Description     string
-	URIParameters   []*Parameter
-	QueryParameters []*Parameter
+	Verb              string       `json:"verb"`
+	Handler           string       `json:"handler"`
+	Path              string       `json:"path"`
+	Description       string       `json:"description,omitempty"`
+	URIParameters     []*Parameter `json:"uriParameters,omitempty"`
+	QueryParameters   []*Parameter `json:"queryParameters,omitempty"`
+	Headers           []*Parameter `json:"headers,omitempty"`
+	FormParameters    []*Parameter `json:"formParameters,omitempty"`
+	BaseURIParameters []*Parameter `json:"baseUriParameters,omitempty"`
+
+	// RequestTypes and ResponseTypes are the media types of the request
+	// and response bodies. Bodies without one have the API's mediaType.
+	RequestTypes  []string `json:"requestTypes,omitempty"`
+	ResponseTypes []string `json:"responseTypes,omitempty"`
+
+	// Protocols are the protocols the endpoint may be called over, HTTP
+	// and HTTPS, from the method or the API, or failing that the scheme of
+	// the baseUri.
+	Protocols []string `json:"protocols,omitempty"`
+
+	// SecuredBy lists the security schemes that may authorize a request,
+	// taken from the method, or failing that its resource or the API.
+	SecuredBy []*Security `json:"securedBy,omitempty"`
+
+	// Method is the RAML method the endpoint was built from, with resource
+	// types and traits applied.
+	Method *raml.Method `json:"-"`
 }
 
 // String returns the string representation of an Endpoint.
@@ -40,34 +65,112 @@ func (e *Endpoint) String() string {
 }
 
 func (e *Endpoint) setQueryParameters(method *raml.Method) {
-	for name, param := range method.QueryParameters {
+	for _, name := range sortedKeys(method.QueryParameters) {
+		param := method.QueryParameters[name]
 		e.QueryParameters = append(e.QueryParameters, newParam(name, &param))
 	}
 }
 
-// Build takes a RAML API definition, a router and a routing map,
-// and wires them all together.
-func Build(api *raml.APIDefinition, routerFunc func(s *Endpoint)) error {
-	for name, resource := range api.Resources {
-		var resourceParams []*Parameter
-		err := processResource("", name, &resource, resourceParams, routerFunc)
-		if err != nil {
-			return err
+func (e *Endpoint) setHeaders(method *raml.Method) {
+	for _, name := range sortedHeaderNames(method.Headers) {
+		param := raml.NamedParameter(method.Headers[raml.HTTPHeader(name)])
+		e.Headers = append(e.Headers, newParam(name, &param))
+	}
+}
+
+// setFormParameters sets the parameters of the method's URL-encoded and
+// multipart form bodies, taking the first declaration of each name.
+func (e *Endpoint) setFormParameters(method *raml.Method, mediaType string) {
+	seen := make(map[string]bool)
+	add := func(params map[string]raml.NamedParameter) {
+		for _, name := range sortedKeys(params) {
+			if !seen[name] {
+				seen[name] = true
+				param := params[name]
+				e.FormParameters = append(e.FormParameters, newParam(name, &param))
+			}
+		}
+	}
+	if isForm(mediaType) {
+		add(method.Bodies.DefaultFormParameters)
+	}
+	for _, mediaType := range sortedKeys(method.Bodies.ForMIMEType) {
+		if isForm(mediaType) {
+			add(method.Bodies.ForMIMEType[mediaType].FormParameters)
 		}
 	}
+}
+
+func isForm(mediaType string) bool {
+	return mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data"
+}
 
-	return nil
+// Build takes a RAML API definition, a router and a routing map,
+// and wires them all together. Options may be given to change how
+// endpoints are named, filtered and logged. Endpoints are built with
+// resource types and traits applied, leaving the definition unchanged.
+func Build(api *raml.APIDefinition, routerFunc func(s *Endpoint), opts ...Option) error {
+	o := newOptions(opts)
+	resources, err := o.resources(api)
+	if err != nil {
+		return err
+	}
+	return walk(resources, func(r *Resource) error {
+		for _, ep := range r.Endpoints {
+			o.logEndpoint(ep)
+			routerFunc(ep)
+		}
+		return nil
+	})
 }
 
 // Process processes a RAML file and returns an API definition.
 func Process(file string) (*raml.APIDefinition, error) {
 	routes, err := raml.ParseFile(file)
 	if err != nil {
-		return nil, fmt.Errorf("Failed parsing RAML file: %s\n", err.Error())
+		return nil, fmt.Errorf("Failed parsing RAML file: %w\n", err)
 	}
 	return routes, nil
 }
 
+// ProcessFS processes the named RAML file in fsys, such as an embed.FS,
+// and returns an API definition. Included files are also read from fsys.
+func ProcessFS(fsys fs.FS, name string) (*raml.APIDefinition, error) {
+	routes, err := raml.ParseFS(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("Failed parsing RAML file: %w\n", err)
+	}
+	return routes, nil
+}
+
+// ProcessBytes processes a RAML document and returns an API definition.
+// Included files are read relative to the working directory.
+func ProcessBytes(b []byte) (*raml.APIDefinition, error) {
+	routes, err := raml.ParseBytes(b, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed parsing RAML: %w\n", err)
+	}
+	return routes, nil
+}
+
+// ProcessReader processes a RAML document read from r and returns an API
+// definition. Included files are read relative to the working directory.
+func ProcessReader(r io.Reader) (*raml.APIDefinition, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("Failed reading RAML: %s\n", err.Error())
+	}
+	return ProcessBytes(b)
+}
+
+// Validate checks a RAML API definition for semantic problems such as
+// unknown keys, undefined traits and examples that don't match their
+// schemas. It returns a *raml.RamlError listing every problem found,
+// located in the original files, or nil if there are none.
+func Validate(api *raml.APIDefinition) error {
+	return raml.Validate(api)
+}
+
 // Variableize normalises RAML display names.
 func Variableize(s string) string {
 	return vizer.ReplaceAllString(strings.Title(s), "")
@@ -75,9 +178,10 @@ func Variableize(s string) string {
 
 func newParam(name string, param *raml.NamedParameter) *Parameter {
 	p := &Parameter{
-		Key:      name,
-		Type:     param.Type,
-		Required: param.Required,
+		Key:        name,
+		Type:       param.Type,
+		Required:   param.Required,
+		Definition: *param,
 	}
 	if param.Pattern != nil {
 		p.Pattern = *param.Pattern
@@ -86,59 +190,94 @@ func newParam(name string, param *raml.NamedParameter) *Parameter {
 	return p
 }
 
-func appendEndpoint(s []*Endpoint, method *raml.Method, params []*Parameter) ([]*Endpoint, error) {
-	if method.DisplayName == "" {
-		return s, errors.New("DisplayName property not set in RAML method")
-	}
-
-	if method != nil {
+func appendEndpoint(s []*Endpoint, path string, method *raml.Method, params []*Parameter, securedBy []raml.DefinitionChoice, o *options) ([]*Endpoint, error) {
+	if method != nil && o.wants(method.Name) {
+		handler := o.namer(path, method)
+		if handler == "" {
+			return s, fmt.Errorf("could not name handler for %s %s", method.Name, path)
+		}
 		ep := &Endpoint{
 			Verb:        method.Name,
-			Handler:     Variableize(method.DisplayName),
+			Handler:     handler,
 			Description: method.Description,
+			Method:      method,
 		}
 		// set query parameters
 		ep.setQueryParameters(method)
+		// set headers
+		ep.setHeaders(method)
 		// set uri parameters
 		for _, param := range params {
 			ep.URIParameters = append(ep.URIParameters, param)
 		}
+		// set form parameters
+		ep.setFormParameters(method, o.mediaType)
+		// set media types
+		ep.RequestTypes = o.mediaTypes(nil, method.Bodies)
+		for _, code := range sortedCodes(method.Responses) {
+			ep.ResponseTypes = o.mediaTypes(ep.ResponseTypes, method.Responses[code].Bodies)
+		}
+		// set protocols, which the method may override
+		ep.Protocols = o.protocols
+		if len(method.Protocols) > 0 {
+			ep.Protocols = upper(method.Protocols)
+		}
+		// set security, which the method may override
+		if len(method.SecuredBy) > 0 {
+			securedBy = method.SecuredBy
+		}
+		ep.SecuredBy = o.security(securedBy)
 		s = append(s, ep)
 	}
 
 	return s, nil
 }
 
-// processResource recursively process resources and their nested children
-// and returns the path so far for the children. The function takes a routerFunc
-// as an argument that is invoked with the verb, resource path and handler as
-// the resources are processed, so the calling code can use pat, mux, httprouter
-// or whatever router they desire and we don't need to know about it.
-func processResource(parent, name string, resource *raml.Resource, params []*Parameter, routerFunc func(s *Endpoint)) error {
-	var path = parent + name
+// processResource recursively processes a resource and its nested
+// children, sorted by path, into a Resource. URI parameters and security
+// schemes are inherited from the parent resources. Handlers are named
+// from the path without prefix, so the base path doesn't change them.
+func processResource(parent *Resource, prefix, parentPath, name string, resource *raml.Resource, params, baseParams []*Parameter, securedBy []raml.DefinitionChoice, o *options) (*Resource, error) {
+	path := parentPath + name
+	r := &Resource{
+		Path:         prefix + path,
+		RelativePath: name,
+		DisplayName:  resource.DisplayName,
+		Description:  resource.Description,
+		Parent:       parent,
+		Resource:     resource,
+	}
 	var err error
-	for name, param := range resource.UriParameters {
+	// Copy the parent's parameters so siblings don't share them
+	params = params[:len(params):len(params)]
+	for _, name := range sortedKeys(resource.UriParameters) {
+		param := resource.UriParameters[name]
 		params = append(params, newParam(name, &param))
 	}
+	r.URIParameters = params
+	if len(resource.SecuredBy) > 0 {
+		securedBy = resource.SecuredBy
+	}
 
-	s := make([]*Endpoint, 0, 6)
 	for _, m := range resource.Methods() {
-		s, err = appendEndpoint(s, m, params)
+		r.Endpoints, err = appendEndpoint(r.Endpoints, path, m, params, securedBy, o)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
-
-	for _, ep := range s {
-		ep.Path = path
-		log.Println("processing", ep)
-		routerFunc(ep)
+	for _, ep := range r.Endpoints {
+		ep.Path = r.Path
+		ep.BaseURIParameters = baseParams
 	}
 
 	// Get all children.
-	for nestname, nested := range resource.Nested {
-		return processResource(path, nestname, nested, params, routerFunc)
+	for _, nestname := range sortedKeys(resource.Nested) {
+		child, err := processResource(r, prefix, path, nestname, resource.Nested[nestname], params, baseParams, securedBy, o)
+		if err != nil {
+			return nil, err
+		}
+		r.Children = append(r.Children, child)
 	}
 
-	return nil
+	return r, nil
 }
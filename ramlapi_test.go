@@ -1,8 +1,21 @@
 package ramlapi_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	. "github.com/EconomistDigitalSolutions/ramlapi"
 	"github.com/buddhamagnet/raml"
@@ -151,6 +164,26 @@ var TestData = []struct {
 			},
 		},
 	},
+	{
+		// test generated and annotated handler names
+		&raml.APIDefinition{
+			Resources: map[string]raml.Resource{
+				"/users/{id}": raml.Resource{
+					Get: &raml.Method{
+						Name: "GET",
+					},
+					Put: &raml.Method{
+						Name:        "PUT",
+						Description: "Replace a user.\n\nx-handler: ReplaceUser",
+					},
+				},
+			},
+		},
+		[]map[string]interface{}{
+			{"verb": "GET", "handler": "GetUsersById", "path": "/users/{id}"},
+			{"verb": "PUT", "handler": "ReplaceUser", "path": "/users/{id}"},
+		},
+	},
 }
 
 func TestProcess(t *testing.T) {
@@ -160,6 +193,174 @@ func TestProcess(t *testing.T) {
 	}
 }
 
+func TestProcessFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"spec/api.raml": &fstest.MapFile{Data: []byte("#%RAML 0.8\ntitle: fs\n/docs:\n  get:\n    description: !include docs.md\n")},
+		"spec/docs.md":  &fstest.MapFile{Data: []byte("Read the docs.\n")},
+	}
+	api, err := ProcessFS(fsys, "spec/api.raml")
+	if err != nil {
+		t.Fatalf("could not process RAML from fs.FS: %v", err)
+	}
+	if r, ok := api.Resources["/docs"]; !ok || r.Get == nil || r.Get.Description != "Read the docs.\n" {
+		t.Errorf("expected included description, got %v", api.Resources)
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	api, err := Process("fixtures/includes/api.raml")
+	if err != nil {
+		t.Fatalf("could not process RAML with includes: %v", err)
+	}
+
+	doc := api.Documentation[0]
+	if doc.Title != "!include is not a tag in quoted strings" || doc.Content != "An API that uses includes.\n" {
+		t.Errorf("expected included documentation, got %+v", doc)
+	}
+	if d := api.Resources["/ping"].Get.Description; d != "Checks the service is up." {
+		t.Errorf("expected included description in flow mapping, got %q", d)
+	}
+	users := api.Resources["/users"]
+	if users.Get == nil || !strings.Contains(users.Get.Responses[200].Bodies.ForMIMEType["application/json"].Schema, `"type": "array"`) {
+		t.Errorf("expected included JSON schema, got %+v", users.Get)
+	}
+	if user, ok := users.Nested["/{id}"]; !ok || user.Get == nil || user.Get.DisplayName != "Get user" {
+		t.Errorf("expected nested include relative to the including file, got %+v", users.Nested)
+	}
+
+	_, err = Process("fixtures/cycle.raml")
+	if err == nil || !strings.Contains(err.Error(), "fixtures/cycle.raml:3:8: cyclic !include of fixtures/cycle.raml") {
+		t.Errorf("expected include cycle to be reported, got %v", err)
+	}
+}
+
+func TestProblems(t *testing.T) {
+	_, err := Process("fixtures/errors/api.raml")
+	var ramlErr *raml.RamlError
+	if !errors.As(err, &ramlErr) || len(ramlErr.Problems) != 1 {
+		t.Fatalf("expected a single RAML problem, got %v", err)
+	}
+
+	p := ramlErr.Problems[0]
+	if p.File != "fixtures/errors/resources/user.raml" || p.Line != 5 || p.Column != 20 {
+		t.Errorf("expected problem at fixtures/errors/resources/user.raml:5:20, got %s:%d:%d", p.File, p.Line, p.Column)
+	}
+	if path := strings.Join(p.Path, " "); path != "/users/{id} get responses 200 description" {
+		t.Errorf("expected problem path through the include, got %q", path)
+	}
+
+	_, err = ProcessBytes([]byte("#%RAML 0.8\ntitle: Bad\nversion: v1\nfoo: @bad\n"))
+	if !errors.As(err, &ramlErr) {
+		t.Fatalf("expected a syntax error, got %v", err)
+	}
+	if p := ramlErr.Problems[0]; p.Line != 4 || p.Column != 6 {
+		t.Errorf("expected syntax error at 4:6, got %d:%d", p.Line, p.Column)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	api, err := Process("fixtures/valid.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Validate(api); err != nil {
+		t.Errorf("expected valid RAML to validate, got %v", err)
+	}
+
+	api, err = Process("fixtures/invalid.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ramlErr *raml.RamlError
+	if err := Validate(api); !errors.As(err, &ramlErr) {
+		t.Fatalf("expected validation problems, got %v", err)
+	}
+
+	expected := []string{
+		`fixtures/invalid.raml:21:5: /users/{id} uriParameters userId: parameter "userId" does not appear in the URI`,
+		`fixtures/invalid.raml:23:3: /users/{id} type: undefined resource type "collection"`,
+		`fixtures/invalid.raml:26:17: /users/{id} get is 1: undefined trait "searchable"`,
+		`fixtures/invalid.raml:27:17: /users/{id} get securedBy 0: undefined security scheme "oauth_2_0"`,
+		`fixtures/invalid.raml:28:5: /users/{id} get colour: unknown key "colour"`,
+		`fixtures/invalid.raml:31:9: /users/{id} get queryParameters limit type: invalid parameter type "int"`,
+		`fixtures/invalid.raml:34:9: /users/{id} get queryParameters sort example: example "random" is not one of [asc desc]`,
+		`fixtures/invalid.raml:40:13: /users/{id} get responses 200 body application/json example: example/id: expected integer, got string`,
+		`fixtures/invalid.raml:42:7: /users/{id} get responses 999: invalid HTTP code 999`,
+		`fixtures/invalid.raml:47:13: /users/{id} get responses 201 body application/json schema: undefined schema "account"`,
+	}
+	// Problems are sorted by position, so come out the same every time
+	var got []string
+	for _, p := range ramlErr.Problems {
+		got = append(got, p.String())
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected problems\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+	if !strings.HasPrefix(ramlErr.Error(), "Invalid RAML:") {
+		t.Errorf("expected a validation error message, got %q", ramlErr.Error())
+	}
+
+	// All the RAML 0.8 methods are known
+	api, err = ProcessBytes([]byte(`#%RAML 0.8
+title: Methods
+/items:
+  options:
+    description: Allowed methods.
+  trace:
+  connect:
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Validate(api); err != nil {
+		t.Errorf("expected options, trace and connect to validate, got %v", err)
+	}
+	items := api.Resources["/items"]
+	if methods := items.Methods(); len(methods) != 1 || methods[0].Name != "OPTIONS" {
+		t.Errorf("expected an OPTIONS method, got %v", methods)
+	}
+
+	// Resource types may inherit from other resource types
+	api, err = ProcessBytes([]byte(`#%RAML 0.8
+title: Inheritance
+resourceTypes:
+  - base:
+      get:
+        description: Get <<resourcePathName>>.
+        responses:
+          200:
+  - collection:
+      type: base
+      post:
+        description: Add to <<resourcePathName>>.
+/items:
+  type: collection
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Validate(api); err != nil {
+		t.Errorf("expected resource type inheritance to validate, got %v", err)
+	}
+	items = raml.Expanded(api).Resources["/items"]
+	if items.Get == nil || items.Get.Description != "Get items." || items.Post == nil || items.Post.Description != "Add to items." {
+		t.Errorf("expected the inherited get and the post, got %+v and %+v", items.Get, items.Post)
+	}
+}
+
+func TestProcessReader(t *testing.T) {
+	b, err := os.ReadFile("fixtures/valid.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ProcessReader(bytes.NewReader(b)); err != nil {
+		t.Errorf("could not process RAML from reader: %v", err)
+	}
+	if _, err := ProcessBytes([]byte("title: no version")); err == nil {
+		t.Error("expected an error processing RAML without a version line")
+	}
+}
+
 func TestEndpoints(t *testing.T) {
 	for _, data := range TestData {
 		Build(data.api, testFunc)
@@ -170,6 +371,1139 @@ func TestEndpoints(t *testing.T) {
 	}
 }
 
+func TestBuildOptions(t *testing.T) {
+	var buf bytes.Buffer
+	api := TestData[0].api
+	namer := func(path string, method *raml.Method) string {
+		return "Custom" + method.Name
+	}
+
+	err := Build(api, testFunc,
+		WithLogger(log.New(&buf, "", 0)),
+		WithNamer(namer),
+		WithBasePath("/v1/"),
+		WithVerbs("post"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []map[string]interface{}{
+		{"verb": "POST", "handler": "CustomPOST", "path": "/v1/test"},
+	}
+	if !checkEndpoints(t, expected, endpoints) {
+		t.Errorf("expected endpoint with: %s", expected)
+	}
+	if !strings.Contains(buf.String(), "processing verb: POST handler: CustomPOST") {
+		t.Errorf("expected endpoint to be logged, got %q", buf.String())
+	}
+	endpoints = make([]*Endpoint, 0)
+}
+
+func TestBasePathNames(t *testing.T) {
+	api, err := ProcessBytes([]byte(`#%RAML 0.8
+title: Users
+/users:
+  get:
+    description: List users.
+  /{id}:
+    get:
+      description: Get a user.
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Build(api, testFunc, Quiet(), WithBasePath("/internal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []map[string]interface{}{
+		{"verb": "GET", "handler": "GetUsers", "path": "/internal/users"},
+		{"verb": "GET", "handler": "GetUsersById", "path": "/internal/users/{id}"},
+	}
+	if !checkEndpoints(t, expected, endpoints) {
+		t.Errorf("expected endpoint with: %s", expected)
+	}
+	endpoints = make([]*Endpoint, 0)
+}
+
+func TestBaseURI(t *testing.T) {
+	api := &raml.APIDefinition{
+		BaseUri: "https://{region}.example.com/{version}/{tenant}/{zone}",
+		Version: "v2",
+		BaseUriParameters: map[string]raml.NamedParameter{
+			"zone": raml.NamedParameter{Default: "eu"},
+		},
+		Resources: TestData[0].api.Resources,
+	}
+
+	err := Build(api, testFunc, Quiet(), WithBaseURI(map[string]string{"region": "us"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []map[string]interface{}{
+		{"verb": "GET", "handler": "GetMe", "path": "/v2/{tenant}/eu/test"},
+		{"verb": "POST", "handler": "PostMe", "path": "/v2/{tenant}/eu/test"},
+	}
+	if !checkEndpoints(t, expected, endpoints) {
+		t.Errorf("expected endpoint with: %s", expected)
+	}
+	for _, ep := range endpoints {
+		if !checkParameters(t, []map[string]string{{"key": "zone"}}, ep.BaseURIParameters) {
+			t.Errorf("expected base uri parameter zone, got %v", ep.BaseURIParameters)
+		}
+	}
+	endpoints = make([]*Endpoint, 0)
+}
+
+func TestBaseURINames(t *testing.T) {
+	api, err := ProcessBytes([]byte(`#%RAML 0.8
+title: Users
+version: v1
+baseUri: https://api.example.com/{version}
+/users:
+  get:
+    description: List users.
+  /{id}:
+    get:
+      description: Get a user.
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Build(api, testFunc, Quiet(), WithBaseURI(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []map[string]interface{}{
+		{"verb": "GET", "handler": "GetUsers", "path": "/v1/users"},
+		{"verb": "GET", "handler": "GetUsersById", "path": "/v1/users/{id}"},
+	}
+	if !checkEndpoints(t, expected, endpoints) {
+		t.Errorf("expected endpoint with: %s", expected)
+	}
+	endpoints = make([]*Endpoint, 0)
+}
+
+func TestVerbPathNamer(t *testing.T) {
+	tests := []struct {
+		verb, path, expected string
+	}{
+		{"GET", "/", "GetRoot"},
+		{"GET", "/users", "GetUsers"},
+		{"DELETE", "/users/{id}", "DeleteUsersById"},
+		{"POST", "/users/{userId}/blog-posts", "PostUsersByUserIdBlogPosts"},
+	}
+
+	for _, test := range tests {
+		got := VerbPathNamer(test.path, &raml.Method{Name: test.verb})
+		if got != test.expected {
+			t.Errorf("expected %s %s to be named %s, got %s", test.verb, test.path, test.expected, got)
+		}
+	}
+}
+
+func TestDocs(t *testing.T) {
+	api, err := Process("fixtures/openapi.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var md bytes.Buffer
+	if err := WriteMarkdown(&md, api); err != nil {
+		t.Fatal(err)
+	}
+	if books := api.Resources["/books"]; books.Get.Description != "" {
+		t.Errorf("expected the spec not to be expanded, got %q", books.Get.Description)
+	}
+	for _, want := range []string{
+		"# Books\n",
+		"## Overview\n\nA library of books.\n",
+		"### GET /books\n\nLists books.\n",
+		"| page | integer | no | Default 1. |\n",
+		"| isbn | string | yes | Matches ^[0-9]{13}$. |\n",
+		"Secured by: key, none\n",
+		"##### 404 Not Found\n\nNo such book.\n",
+		"Example:\n\n```\n{\"id\": 1, \"title\": \"Ulysses\"}\n```\n",
+	} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("expected Markdown to contain %q, got:\n%s", want, md.String())
+		}
+	}
+
+	var html bytes.Buffer
+	if err := WriteHTML(&html, api); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<title>Books</title>",
+		`<li><a href="#get-books-isbn"><span class="verb">GET</span> /books/{isbn}</a></li>`,
+		`<section id="post-books">`,
+		"<pre>{&#34;id&#34;: 1, &#34;title&#34;: &#34;Ulysses&#34;}</pre>",
+	} {
+		if !strings.Contains(html.String(), want) {
+			t.Errorf("expected HTML to contain %q, got:\n%s", want, html.String())
+		}
+	}
+}
+
+func TestDocsHandler(t *testing.T) {
+	api, err := Process("fixtures/includes/api.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := http.StripPrefix("/docs", DocsHandler(api))
+
+	get := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	tests := []struct {
+		path, contentType, contains string
+	}{
+		{"/docs/", "text/html; charset=utf-8", "<title>includes</title>"},
+		{"/docs/docs.md", "text/markdown; charset=utf-8", "### GET /ping"},
+		{"/docs/api.raml", "application/raml+yaml", "description: Checks the service is up."},
+		{"/docs/endpoints.json", "application/json", `"handler": "ListUsers"`},
+	}
+	for _, test := range tests {
+		w := get("GET", test.path)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", test.path, w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != test.contentType {
+			t.Errorf("%s: expected Content-Type %s, got %s", test.path, test.contentType, ct)
+		}
+		if !strings.Contains(w.Body.String(), test.contains) {
+			t.Errorf("%s: expected body to contain %q, got:\n%s", test.path, test.contains, w.Body.String())
+		}
+	}
+
+	var eps []*Endpoint
+	if err := json.Unmarshal(get("GET", "/docs/endpoints.json").Body.Bytes(), &eps); err != nil {
+		t.Fatal(err)
+	}
+	if len(eps) != 3 {
+		t.Errorf("expected 3 endpoints, got %d", len(eps))
+	}
+
+	if raw := get("GET", "/docs/api.raml").Body.String(); !strings.HasPrefix(raw, "#%RAML 0.8\n") || strings.Contains(raw, "!include docs") {
+		t.Errorf("expected RAML with includes resolved, got:\n%s", raw)
+	}
+	if w := get("GET", "/docs/missing"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+	if w := get("POST", "/docs/"); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+
+	// Endpoints have what traits add, but the spec is left as it is
+	api, err = Process("fixtures/openapi.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h = http.StripPrefix("/docs", DocsHandler(api))
+	if body := get("GET", "/docs/endpoints.json").Body.String(); !strings.Contains(body, `"key": "page"`) {
+		t.Errorf("expected the trait's query parameter, got:\n%s", body)
+	}
+	if books := api.Resources["/books"]; books.Get.Description != "" {
+		t.Errorf("expected the spec not to be expanded, got %q", books.Get.Description)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	from, err := Process("fixtures/diff/v1.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := Process("fixtures/diff/v2.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"breaking: DELETE /users/{id}: endpoint removed",
+		"breaking: GET /users: query parameter limit range narrowed",
+		"non-breaking: GET /users: optional query parameter page added",
+		"breaking: GET /users: query parameter q pattern ^[a-z]+$ added",
+		"breaking: GET /users: query parameter sort enum narrowed, created removed",
+		"breaking: GET /users: response 200 media type application/xml removed",
+		"non-breaking: GET /users: response 206 added",
+		"breaking: GET /users/{id}: URI parameter id type changed from string to integer",
+		"breaking: GET /users/{id}: response 404 removed",
+		"breaking: POST /users: required header x-request-id added",
+		"breaking: POST /users: required form parameter email added",
+		"non-breaking: PUT /users/{id}: endpoint added",
+	}
+	changes := Diff(from, to)
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %d: %v", len(expected), len(changes), changes)
+	}
+	for i, c := range changes {
+		if c.String() != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i], c.String())
+		}
+	}
+	if !BreakingChanges(changes) {
+		t.Error("expected breaking changes")
+	}
+
+	if changes := Diff(to, to); len(changes) != 0 {
+		t.Errorf("expected no changes comparing a spec with itself, got %v", changes)
+	}
+
+	// Specs are compared expanded, but left as they are
+	api, err := Process("fixtures/openapi.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes := Diff(api, api); len(changes) != 0 {
+		t.Errorf("expected no changes comparing a spec with itself, got %v", changes)
+	}
+	if books := api.Resources["/books"]; books.Get.Description != "" {
+		t.Errorf("expected the spec not to be expanded, got %q", books.Get.Description)
+	}
+}
+
+func TestSampleValues(t *testing.T) {
+	pattern, min, max, minLength := `^[0-9]{13}$`, 5.0, 10.0, 3
+	params := []raml.NamedParameter{
+		{},
+		{Pattern: &pattern},
+		{Type: "integer", Minimum: &min, Maximum: &max},
+		{Type: "boolean"},
+		{Type: "date"},
+		{Enum: []raml.Any{"asc", "desc"}},
+		{MinLength: &minLength, Example: "abcd"},
+	}
+	for _, p := range params {
+		v, ok := SampleValue(p)
+		if !ok {
+			t.Errorf("%+v: expected a sample value", p)
+		}
+		if err := raml.CheckParameter(p, v); err != nil {
+			t.Errorf("%+v: expected %q to be valid, got %s", p, v, err)
+		}
+		for _, invalid := range InvalidValues(p) {
+			if raml.CheckParameter(p, invalid.Value) == nil {
+				t.Errorf("%+v: expected %q to be invalid (%s)", p, invalid.Value, invalid.Reason)
+			}
+		}
+	}
+
+	if v, _ := SampleValue(params[6]); v != "abcd" {
+		t.Errorf("expected the example to be preferred, got %q", v)
+	}
+	var reasons []string
+	for _, invalid := range InvalidValues(params[2]) {
+		reasons = append(reasons, invalid.Reason)
+	}
+	if strings.Join(reasons, " ") != "type minimum maximum" {
+		t.Errorf("expected type, minimum and maximum violations, got %v", reasons)
+	}
+	if invalid := InvalidValues(params[0]); len(invalid) != 0 {
+		t.Errorf("expected no invalid values for an unconstrained string, got %v", invalid)
+	}
+
+	unanchored := `[a-z]`
+	p := raml.NamedParameter{Pattern: &unanchored}
+	for value, valid := range map[string]bool{"abc": true, "1a": true, "123": false} {
+		if err := raml.CheckParameter(p, value); (err == nil) != valid {
+			t.Errorf("pattern %s with %q: expected valid %t, got %v", unanchored, value, valid, err)
+		}
+	}
+}
+
+func TestSecurity(t *testing.T) {
+	api, err := ProcessBytes([]byte(`#%RAML 0.8
+title: Secure
+securitySchemes:
+  - basic:
+      type: Basic Authentication
+  - oauth:
+      type: OAuth 2.0
+  - key:
+      type: x-api-key
+      describedBy:
+        headers:
+          X-Api-Key:
+securedBy: [basic]
+/public:
+  securedBy: [null]
+  get:
+    description: Anyone.
+/users:
+  get:
+    description: Basic.
+  post:
+    securedBy: [oauth: { scopes: [ ADMINISTRATOR, write ] }, key]
+    description: OAuth or key.
+  delete:
+    securedBy: [null, oauth: { scopes: [ ADMINISTRATOR ] }]
+    description: Anyone, or OAuth.
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	eps := make(map[string]*Endpoint)
+	if err := Build(api, func(ep *Endpoint) { eps[ep.Verb+" "+ep.Path] = ep }, Quiet()); err != nil {
+		t.Fatal(err)
+	}
+
+	names := func(ep *Endpoint) string {
+		var list []string
+		for _, s := range ep.SecuredBy {
+			list = append(list, s.Name+"("+s.Type+")"+strings.Join(s.Scopes, ","))
+		}
+		return strings.Join(list, " ")
+	}
+	securedBy := map[string]string{
+		"GET /public":   "null()",
+		"GET /users":    "basic(Basic Authentication)",
+		"POST /users":   "oauth(OAuth 2.0)ADMINISTRATOR,write key(x-api-key)",
+		"DELETE /users": "null() oauth(OAuth 2.0)ADMINISTRATOR",
+	}
+	for key, want := range securedBy {
+		if got := names(eps[key]); got != want {
+			t.Errorf("%s: expected securedBy %q, got %q", key, want, got)
+		}
+	}
+
+	auth := map[string]Authenticator{
+		"Basic Authentication": BasicAuth(func(user, password string) bool { return user == "ann" && password == "secret" }),
+		"OAuth 2.0": BearerAuth(func(token string, scopes []string) bool {
+			return token == "t0k3n" && strings.Join(scopes, ",") == "ADMINISTRATOR,write"
+		}),
+		"key": KeyAuth(func(key string) bool { return key == "k3y" }),
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s := SecurityFrom(r.Context()); s != nil {
+			w.Header().Set("X-Scheme", s.Name)
+		}
+	})
+	tests := []struct {
+		endpoint, header, value string
+		code                    int
+		scheme                  string
+	}{
+		{"GET /public", "", "", 200, ""},
+		{"GET /users", "", "", 401, ""},
+		{"GET /users", "Authorization", "Basic YW5uOnNlY3JldA==", 200, "basic"},
+		{"GET /users", "Authorization", "Basic YW5uOndyb25n", 401, ""},
+		{"POST /users", "Authorization", "Bearer t0k3n", 200, "oauth"},
+		{"POST /users", "X-Api-Key", "k3y", 200, "key"},
+		{"POST /users", "Authorization", "Basic YW5uOnNlY3JldA==", 401, ""},
+	}
+	for _, test := range tests {
+		ep := eps[test.endpoint]
+		r := httptest.NewRequest(ep.Verb, ep.Path, nil)
+		if test.header != "" {
+			r.Header.Set(test.header, test.value)
+		}
+		w := httptest.NewRecorder()
+		Secure(ep, ok, auth).ServeHTTP(w, r)
+		if w.Code != test.code || w.Header().Get("X-Scheme") != test.scheme {
+			t.Errorf("%s with %s %q: expected %d by %q, got %d by %q", test.endpoint, test.header, test.value,
+				test.code, test.scheme, w.Code, w.Header().Get("X-Scheme"))
+		}
+	}
+
+	w := httptest.NewRecorder()
+	Secure(eps["GET /users"], ok, auth).ServeHTTP(w, httptest.NewRequest("GET", "/users", nil))
+	if got := w.Header().Get("WWW-Authenticate"); got != `Basic realm="basic"` {
+		t.Errorf("expected a Basic challenge, got %q", got)
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	api, err := ProcessBytes([]byte(`#%RAML 0.8
+title: Tenancy
+traits:
+  - tenanted:
+      headers:
+        X-Tenant:
+          required: true
+          pattern: ^[a-z]+$
+/items/{id}:
+  uriParameters:
+    id:
+      type: integer
+  get:
+    is: [tenanted]
+    description: Get an item.
+    queryParameters:
+      fields:
+        enum: [all, summary]
+    headers:
+      x-request-id:
+        minLength: 4
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ep *Endpoint
+	if err := Build(api, func(e *Endpoint) { ep = e }, Quiet()); err != nil {
+		t.Fatal(err)
+	}
+
+	var headers []string
+	for _, h := range ep.Headers {
+		headers = append(headers, fmt.Sprintf("%s:%t", h.Key, h.Required))
+	}
+	if strings.Join(headers, " ") != "X-Tenant:true x-request-id:false" {
+		t.Errorf("expected the method and trait headers, got %v", headers)
+	}
+
+	tests := []struct {
+		target   string
+		headers  map[string]string
+		problems []string
+	}{
+		{"/items/1", map[string]string{"x-tenant": "acme", "X-REQUEST-ID": "abcd"}, nil},
+		{"/items/1?fields=all", map[string]string{"X-Tenant": "acme"}, nil},
+		{"/items/1", nil, []string{"missing header X-Tenant"}},
+		{"/items/one?fields=none", map[string]string{"X-Tenant": "ACME", "X-Request-Id": "ab"}, []string{
+			`URI parameter id: "one" is not an integer`,
+			"query parameter fields: ",
+			"header X-Tenant: ",
+			"header x-request-id: ",
+		}},
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", test.target, nil)
+		for name, value := range test.headers {
+			r.Header[name] = []string{value}
+		}
+		w := httptest.NewRecorder()
+		ValidateRequest(ep, next).ServeHTTP(w, r)
+
+		var problems []string
+		if err := CheckRequest(ep, r); err != nil {
+			problems = err.(*RequestError).Problems
+		}
+		ok := len(problems) == len(test.problems) && (w.Code == http.StatusOK) == (len(problems) == 0)
+		for i := 0; ok && i < len(problems); i++ {
+			ok = strings.HasPrefix(problems[i], test.problems[i])
+		}
+		if !ok {
+			t.Errorf("%s %v: expected problems %q, got %d %q", test.target, test.headers, test.problems, w.Code, problems)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	api, err := ProcessBytes([]byte(`#%RAML 0.8
+title: Reports
+mediaType: application/json
+/reports:
+  post:
+    description: Add a report.
+    body:
+      example: |
+        {"title": "Q1"}
+      text/csv:
+    responses:
+      201:
+        body:
+          example: |
+            {"id": 1}
+          application/xml:
+      400:
+        body:
+          text/plain:
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ep *Endpoint
+	if err := Build(api, func(e *Endpoint) { ep = e }, Quiet()); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(ep.RequestTypes, " "); got != "application/json text/csv" {
+		t.Errorf("expected request types application/json text/csv, got %s", got)
+	}
+	if got := strings.Join(ep.ResponseTypes, " "); got != "application/json application/xml text/plain" {
+		t.Errorf("expected response types application/json application/xml text/plain, got %s", got)
+	}
+
+	tests := []struct {
+		contentType, accept string
+		code                int
+		negotiated          string
+	}{
+		{"application/json; charset=utf-8", "", 200, "application/json"},
+		{"text/csv", "application/xml", 200, "application/xml"},
+		{"text/csv", "text/*;q=0.5, application/xml;q=0.4", 200, "text/plain"},
+		{"text/csv", "*/*;q=0.1, application/json;q=0", 200, "application/xml"},
+		{"application/xml", "", 415, ""},
+		{"", "", 415, ""},
+		{"application/json", "image/png", 406, ""},
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", NegotiatedType(r.Context()))
+	})
+	for _, test := range tests {
+		r := httptest.NewRequest("POST", "/reports", strings.NewReader("body"))
+		if test.contentType != "" {
+			r.Header.Set("Content-Type", test.contentType)
+		}
+		if test.accept != "" {
+			r.Header.Set("Accept", test.accept)
+		}
+		w := httptest.NewRecorder()
+		Negotiate(ep, next).ServeHTTP(w, r)
+		if w.Code != test.code || (test.code == 200 && w.Header().Get("Content-Type") != test.negotiated) {
+			t.Errorf("Content-Type %q, Accept %q: expected %d %s, got %d %s", test.contentType, test.accept,
+				test.code, test.negotiated, w.Code, w.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestValidateForm(t *testing.T) {
+	api, err := Process("fixtures/forms.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ep *Endpoint
+	if err := Build(api, func(e *Endpoint) { ep = e }, Quiet()); err != nil {
+		t.Fatal(err)
+	}
+	var params []string
+	for _, p := range ep.FormParameters {
+		params = append(params, p.Key)
+	}
+	if got := strings.Join(params, " "); got != "caption url file public rating taken-at" {
+		t.Errorf("expected form parameters from both bodies, got %s", got)
+	}
+
+	multipartBody := func(fields map[string]string, file bool) (string, string) {
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		for k, v := range fields {
+			w.WriteField(k, v)
+		}
+		if file {
+			part, _ := w.CreateFormFile("file", "photo.jpg")
+			part.Write([]byte("jpeg"))
+		}
+		w.Close()
+		return w.FormDataContentType(), buf.String()
+	}
+
+	type formTest struct {
+		name, contentType, body string
+		problems                []string
+	}
+	tests := []formTest{
+		{"url-encoded", "application/x-www-form-urlencoded", "url=http://x&caption=Hi", []string{"missing form file file"}},
+		{"missing url", "application/x-www-form-urlencoded", "caption=Hi", []string{"missing form parameter url", "missing form file file"}},
+		{"not a form", "application/json", "{}", nil},
+	}
+	contentType, body := multipartBody(map[string]string{"url": "http://x", "rating": "5", "public": "true"}, true)
+	tests = append(tests, formTest{"multipart", contentType, body, nil})
+	contentType, body = multipartBody(map[string]string{"url": "http://x", "rating": "6"}, false)
+	tests = append(tests, formTest{"bad multipart", contentType, body, []string{"missing form file file", `form parameter rating: "6" is greater than 5`}})
+
+	for _, test := range tests {
+		r := httptest.NewRequest("POST", "/photos", strings.NewReader(test.body))
+		r.Header.Set("Content-Type", test.contentType)
+		var problems []string
+		if err := CheckRequest(ep, r); err != nil {
+			problems = err.(*RequestError).Problems
+		}
+		if fmt.Sprint(problems) != fmt.Sprint(test.problems) {
+			t.Errorf("%s: expected problems %q, got %q", test.name, test.problems, problems)
+		}
+	}
+}
+
+func TestRequireHTTPS(t *testing.T) {
+	api, err := ProcessBytes([]byte(`#%RAML 0.8
+title: Shop
+baseUri: https://shop.example.com
+/products:
+  get:
+    description: Anyone, over HTTP or HTTPS.
+    protocols: [HTTP, HTTPS]
+  post:
+    description: Over HTTPS only.
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	eps := make(map[string]*Endpoint)
+	if err := Build(api, func(ep *Endpoint) { eps[ep.Verb] = ep }, Quiet()); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(eps["GET"].Protocols, " "); got != "HTTP HTTPS" {
+		t.Errorf("expected GET over HTTP HTTPS, got %s", got)
+	}
+	if got := strings.Join(eps["POST"].Protocols, " "); got != "HTTPS" {
+		t.Errorf("expected POST over HTTPS from the baseUri, got %s", got)
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	tests := []struct {
+		verb, target, proto, host string
+		trustProxy                bool
+		code                      int
+		location                  string
+	}{
+		{"GET", "http://shop.example.com/products", "", "", false, 200, ""},
+		{"POST", "http://shop.example.com/products", "", "", false, 403, ""},
+		{"POST", "http://shop.example.com/products?id=1", "", "shop.example.com", false, 308, "https://shop.example.com/products?id=1"},
+		{"GET", "http://evil.example.com/products", "", "shop.example.com", false, 200, ""},
+		{"POST", "http://evil.example.com/products", "", "shop.example.com", false, 308, "https://shop.example.com/products"},
+		{"POST", "http://shop.example.com/products", "https", "", false, 403, ""},
+		{"POST", "http://shop.example.com/products", "https", "", true, 200, ""},
+		{"POST", "http://shop.example.com/products", "HTTP, https", "shop.example.com", true, 308, "https://shop.example.com/products"},
+		{"POST", "https://shop.example.com/products", "", "", false, 200, ""},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(test.verb, test.target, nil)
+		if test.proto != "" {
+			r.Header.Set("X-Forwarded-Proto", test.proto)
+		}
+		w := httptest.NewRecorder()
+		RequireHTTPS(eps[test.verb], next, test.host, test.trustProxy).ServeHTTP(w, r)
+		if w.Code != test.code || w.Header().Get("Location") != test.location {
+			t.Errorf("%s %s (%q, host %q, trust %t): expected %d %s, got %d %s", test.verb, test.target, test.proto,
+				test.host, test.trustProxy, test.code, test.location, w.Code, w.Header().Get("Location"))
+		}
+	}
+}
+
+func TestEnforceResponses(t *testing.T) {
+	api, err := Process("fixtures/fuzz.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ep *Endpoint
+	if err := Build(api, func(e *Endpoint) {
+		if e.Path == "/items/{id}" {
+			ep = e
+		}
+	}, Quiet()); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := func(status int, etag string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if etag != "" {
+				w.Header().Set("ETag", etag)
+			}
+			w.WriteHeader(status)
+			w.Write([]byte("item"))
+		})
+	}
+	tests := []struct {
+		status   int
+		etag     string
+		problems string
+	}{
+		{200, `"1"`, ""},
+		{404, "", ""},
+		{200, "", "missing header ETag"},
+		{418, "", "undeclared status 418"},
+	}
+	for _, mode := range []ResponseMode{ReportMode, StrictMode} {
+		for _, test := range tests {
+			var reported *ResponseError
+			w := httptest.NewRecorder()
+			EnforceResponses(ep, handler(test.status, test.etag), mode, func(err *ResponseError) {
+				reported = err
+			}).ServeHTTP(w, httptest.NewRequest("GET", "/items/1", nil))
+
+			var problems string
+			if reported != nil {
+				problems = strings.Join(reported.Problems, "; ")
+			}
+			if problems != test.problems {
+				t.Errorf("mode %d, %d %q: expected problems %q, got %q", mode, test.status, test.etag, test.problems, problems)
+			}
+			code, body := test.status, "item"
+			if mode == StrictMode && test.problems != "" {
+				code, body = http.StatusInternalServerError, "response breaks the spec:\n"+test.problems+"\n"
+			}
+			if w.Code != code || w.Body.String() != body {
+				t.Errorf("mode %d, %d %q: expected %d %q, got %d %q", mode, test.status, test.etag, code, body, w.Code, w.Body.String())
+			}
+		}
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	api, err := Process("fixtures/fuzz.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	coverage := NewCoverage()
+	mux := http.NewServeMux()
+	err = Build(api, func(ep *Endpoint) {
+		if ep.Path == "/items" {
+			mux.Handle(ep.Path, coverage.Record(ep, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("limit") == "0" {
+					w.WriteHeader(http.StatusTeapot)
+				}
+			})))
+			return
+		}
+		coverage.Record(ep, http.NotFoundHandler())
+	}, Quiet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range []string{"/items", "/items?limit=5", "/items?limit=0"} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", target, nil))
+	}
+
+	report := coverage.Report()
+	// Endpoints 1/2, responses 1/5, parameters 1/2
+	if report.Covered != 3 || report.Total != 9 {
+		t.Errorf("expected 3/9 covered, got %d/%d", report.Covered, report.Total)
+	}
+
+	var text bytes.Buffer
+	if err := report.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"coverage: 3/9 (33.3%)",
+		"GET /items ListItems: 3 requests\n",
+		"  response 200: 2\n",
+		"  response 400: 0 (not covered)\n",
+		"  response 418: 1 (undeclared)\n",
+		"  query limit: 2\n",
+		"GET /items/{id} GetItem: 0 requests (not covered)\n",
+		"  header X-Mode: 0 (not covered)\n",
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("expected text report to contain %q, got:\n%s", want, text.String())
+		}
+	}
+
+	var js bytes.Buffer
+	if err := report.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	var decoded CoverageReport
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil || len(decoded.Endpoints) != 2 || decoded.Endpoints[0].Requests != 3 {
+		t.Errorf("expected JSON report of 2 endpoints, got %s (%v)", js.String(), err)
+	}
+
+	var html bytes.Buffer
+	if err := report.WriteHTML(&html); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html.String(), `<h2 class="missed">GET /items/{id}</h2>`) {
+		t.Errorf("expected HTML report to mark GET /items/{id} missed, got:\n%s", html.String())
+	}
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	spec := filepath.Join(dir, "api.raml")
+	include := filepath.Join(dir, "items.raml")
+	// write writes a file, moving its modification time on so the change
+	// is seen even on file systems with coarse timestamps.
+	mtime := time.Now()
+	write := func(name, content string) {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mtime = mtime.Add(time.Second)
+		os.Chtimes(name, mtime, mtime)
+	}
+	write(spec, "#%RAML 0.8\ntitle: Items\n/items: !include items.raml\n")
+	write(include, "get:\n  displayName: List items\n")
+
+	build := func(api *raml.APIDefinition) (http.Handler, error) {
+		mux := http.NewServeMux()
+		err := Build(api, func(ep *Endpoint) {
+			handler := ep.Handler
+			mux.HandleFunc(ep.Path, func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, handler) })
+		}, Quiet())
+		return mux, err
+	}
+	reloader, err := NewReloader(spec, build)
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := func() string {
+		w := httptest.NewRecorder()
+		reloader.ServeHTTP(w, httptest.NewRequest("GET", "/items", nil))
+		return w.Body.String()
+	}
+	if got := served(); got != "ListItems" {
+		t.Errorf("expected ListItems, got %q", got)
+	}
+	if files := reloader.API().Files(); len(files) != 2 || !strings.HasSuffix(files[1], "/items.raml") {
+		t.Errorf("expected the spec and its include, got %v", files)
+	}
+	if reloader.Changed() {
+		t.Error("expected no change")
+	}
+
+	write(include, "get:\n  displayName: All items\n")
+	if !reloader.Changed() {
+		t.Fatal("expected a change to the include to be seen")
+	}
+	if err := reloader.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := served(); got != "AllItems" {
+		t.Errorf("expected AllItems after reloading, got %q", got)
+	}
+
+	// An invalid spec is reported and the old handler kept
+	write(include, "get:\n  is: [missing]\n  displayName: Broken\n")
+	if err := reloader.Reload(); err == nil || !strings.Contains(err.Error(), `undefined trait "missing"`) {
+		t.Errorf("expected the invalid spec to be reported, got %v", err)
+	}
+	if got := served(); got != "AllItems" {
+		t.Errorf("expected AllItems to be kept, got %q", got)
+	}
+	if reloader.Changed() {
+		t.Error("expected the broken spec not to count as a change until edited")
+	}
+
+	// Stop watching before the files are removed
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+	go func() {
+		reloader.Watch(ctx, 10*time.Millisecond, func(err error) { t.Error(err) })
+		close(stopped)
+	}()
+	write(include, "get:\n  displayName: Fixed items\n")
+	for deadline := time.Now().Add(5 * time.Second); served() != "FixedItems"; {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected Watch to reload the fixed spec, got %q", served())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestManifest(t *testing.T) {
+	api, err := Process("fixtures/fuzz.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Manifest(api)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []struct {
+		Verb            string
+		Path            string
+		Handler         string
+		QueryParameters []*Parameter
+		Headers         []*Parameter
+		Responses       []struct {
+			Code    int
+			Headers []*Parameter
+			Bodies  []struct{ MediaType string }
+		}
+	}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 endpoints, got %d: %s", len(got), b)
+	}
+
+	list, item := got[0], got[1]
+	if list.Path != "/items" || list.Verb != "GET" || list.Handler != "ListItems" {
+		t.Errorf("unexpected first endpoint %s %s %s", list.Verb, list.Path, list.Handler)
+	}
+	if len(list.QueryParameters) != 1 || list.QueryParameters[0].Key != "limit" || list.QueryParameters[0].Type != "integer" {
+		t.Errorf("unexpected query parameters %+v", list.QueryParameters)
+	}
+	if len(list.Responses) != 2 || list.Responses[0].Code != 200 || list.Responses[1].Code != 400 {
+		t.Fatalf("unexpected responses %+v", list.Responses)
+	}
+	if bodies := list.Responses[0].Bodies; len(bodies) != 1 || bodies[0].MediaType != "application/json" {
+		t.Errorf("unexpected response bodies %+v", bodies)
+	}
+
+	if item.Path != "/items/{id}" || item.Handler != "GetItem" {
+		t.Errorf("unexpected second endpoint %s %s %s", item.Verb, item.Path, item.Handler)
+	}
+	if len(item.Headers) != 1 || item.Headers[0].Key != "X-Mode" || !item.Headers[0].Required {
+		t.Errorf("unexpected headers %+v", item.Headers)
+	}
+	if len(item.Responses) != 3 {
+		t.Fatalf("unexpected responses %+v", item.Responses)
+	}
+	if headers := item.Responses[0].Headers; len(headers) != 1 || headers[0].Key != "ETag" || !headers[0].Required {
+		t.Errorf("unexpected response headers %+v", headers)
+	}
+
+	api, err = Process("fixtures/openapi.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Manifest(api); err != nil {
+		t.Fatal(err)
+	}
+	if books := api.Resources["/books"]; books.Get.Description != "" {
+		t.Errorf("expected the spec not to be expanded, got %q", books.Get.Description)
+	}
+}
+
+func TestBuildExpanded(t *testing.T) {
+	api, err := Process("fixtures/openapi.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	descriptions := make(map[string]string)
+	if err := Build(api, func(ep *Endpoint) { descriptions[ep.Verb+" "+ep.Path] = ep.Description }, Quiet()); err != nil {
+		t.Fatal(err)
+	}
+	if got := descriptions["GET /books"]; got != "Lists books." {
+		t.Errorf("expected the description from the resource type, got %q", got)
+	}
+	if books := api.Resources["/books"]; books.Get.Description != "" {
+		t.Errorf("expected the spec not to be expanded, got %q", books.Get.Description)
+	}
+
+	// Methods only the resource type declares are routed too
+	api, err = ProcessBytes([]byte(`#%RAML 0.8
+title: Items
+resourceTypes:
+  - collection:
+      get:
+        displayName: List items
+/items:
+  type: collection
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Build(api, testFunc, Quiet()); err != nil {
+		t.Fatal(err)
+	}
+	checkEndpoints(t, []map[string]interface{}{{"verb": "GET", "handler": "ListItems", "path": "/items"}}, endpoints)
+	endpoints = make([]*Endpoint, 0)
+}
+
+func TestBuildNestedSiblings(t *testing.T) {
+	api, err := ProcessBytes([]byte(`#%RAML 0.8
+title: Users
+/users:
+  /{id}:
+    get:
+      displayName: Get user
+    /posts:
+      get:
+        displayName: List posts
+    /followers:
+      get:
+        displayName: List followers
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes := make(map[string]bool)
+	err = Build(api, func(ep *Endpoint) {
+		routes[ep.Verb+" "+ep.Path] = true
+	}, Quiet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"GET /users/{id}", "GET /users/{id}/posts", "GET /users/{id}/followers"} {
+		if !routes[want] {
+			t.Errorf("expected a route for %s, got %v", want, routes)
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	api, err := ProcessBytes([]byte(`#%RAML 0.8
+title: Users
+/users:
+  displayName: Users
+  get:
+    displayName: List users
+  /{id}:
+    description: A user.
+    uriParameters:
+      id:
+        type: integer
+    get:
+      displayName: Get user
+    delete:
+      displayName: Delete user
+    /posts:
+      get:
+        displayName: List posts
+    /followers:
+      get:
+        displayName: List followers
+/health:
+  get:
+    displayName: Health
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	err = Walk(api, func(r *Resource) error {
+		var keys, verbs []string
+		for _, p := range r.URIParameters {
+			keys = append(keys, p.Key)
+		}
+		for _, ep := range r.Endpoints {
+			verbs = append(verbs, ep.Verb)
+		}
+		got = append(got, fmt.Sprintf("%s %s %v %v %d", r.Path, r.RelativePath, keys, verbs, len(r.Children)))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []string{
+		"/health /health [] [GET] 0",
+		"/users /users [] [GET] 1",
+		"/users/{id} /{id} [id] [GET DELETE] 2",
+		"/users/{id}/followers /followers [id] [GET] 0",
+		"/users/{id}/posts /posts [id] [GET] 0",
+	}
+	if strings.Join(got, "\n") != strings.Join(exp, "\n") {
+		t.Errorf("expected\n%s\ngot\n%s", strings.Join(exp, "\n"), strings.Join(got, "\n"))
+	}
+
+	resources, err := Resources(api)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := resources[1]
+	if users.DisplayName != "Users" || users.Children[0].Description != "A user." {
+		t.Errorf("expected display name and description, got %q and %q", users.DisplayName, users.Children[0].Description)
+	}
+	if posts := users.Children[0].Children[1]; posts.Parent != users.Children[0] || posts.Parent.Parent != users {
+		t.Error("expected parents to be set")
+	}
+
+	// SkipChildren skips the nested resources only
+	got = nil
+	Walk(api, func(r *Resource) error {
+		got = append(got, r.Path)
+		if r.Path == "/users/{id}" {
+			return SkipChildren
+		}
+		return nil
+	})
+	if len(got) != 3 {
+		t.Errorf("expected 3 resources, got %v", got)
+	}
+}
+
 func checkEndpoints(t *testing.T, exp []map[string]interface{}, got []*Endpoint) bool {
 	var foundHandler, foundPath, foundVerb bool
 	var found int
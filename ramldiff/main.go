@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/EconomistDigitalSolutions/ramlapi"
+)
+
+var (
+	oldFile string
+	newFile string
+	format  string
+)
+
+func init() {
+	flag.StringVar(&oldFile, "old", "", "RAML file of the previous version")
+	flag.StringVar(&newFile, "new", "api.raml", "RAML file of the new version")
+	flag.StringVar(&format, "format", "human", "Output format: human or json")
+}
+
+func main() {
+	flag.Parse()
+	if oldFile == "" {
+		log.Fatal("-old is required")
+	}
+	if format != "human" && format != "json" {
+		log.Fatalf("unknown format %q", format)
+	}
+
+	from, err := ramlapi.Process(oldFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	to, err := ramlapi.Process(newFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	changes := ramlapi.Diff(from, to)
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(changes); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		for _, c := range changes {
+			fmt.Println(c)
+		}
+	}
+
+	if code := exitCode(changes, from.Version, to.Version); code != 0 {
+		fmt.Fprintf(os.Stderr, "breaking changes without a version bump from %q\n", from.Version)
+		os.Exit(code)
+	}
+}
+
+// exitCode fails if there are breaking changes and the version is
+// unchanged.
+func exitCode(changes []ramlapi.Change, oldVersion, newVersion string) int {
+	if ramlapi.BreakingChanges(changes) && oldVersion == newVersion {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/EconomistDigitalSolutions/ramlapi"
+)
+
+func TestExitCode(t *testing.T) {
+	breaking := []ramlapi.Change{{Breaking: true, Endpoint: "GET /users", Message: "endpoint removed"}}
+	compatible := []ramlapi.Change{{Endpoint: "GET /users", Message: "endpoint added"}}
+
+	tests := []struct {
+		changes    []ramlapi.Change
+		oldVersion string
+		newVersion string
+		code       int
+	}{
+		{breaking, "v1", "v1", 1},
+		{breaking, "v1", "v2", 0},
+		{compatible, "v1", "v1", 0},
+		{nil, "v1", "v1", 0},
+	}
+	for _, test := range tests {
+		if code := exitCode(test.changes, test.oldVersion, test.newVersion); code != test.code {
+			t.Errorf("%v %s -> %s: expected exit code %d, got %d", test.changes, test.oldVersion, test.newVersion, test.code, code)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"github.com/EconomistDigitalSolutions/ramlapi"
+	"github.com/buddhamagnet/raml"
+)
+
+var (
+	ramlFile string
+	format   string
+	output   string
+)
+
+var formats = map[string]func(io.Writer, *raml.APIDefinition) error{
+	"html":     ramlapi.WriteHTML,
+	"markdown": ramlapi.WriteMarkdown,
+}
+
+func init() {
+	flag.StringVar(&ramlFile, "ramlfile", "api.raml", "RAML file to document")
+	flag.StringVar(&format, "format", "html", "Output format: html or markdown")
+	flag.StringVar(&output, "output", "", "File to write, instead of stdout")
+}
+
+func main() {
+	flag.Parse()
+	write, ok := formats[format]
+	if !ok {
+		log.Fatalf("unknown format %q", format)
+	}
+
+	api, err := ramlapi.Process(ramlFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	w := os.Stdout
+	if output != "" {
+		if w, err = os.Create(output); err != nil {
+			log.Fatal(err)
+		}
+	}
+	err = write(w, api)
+	// Close the file before exiting either way, and catch a failed write
+	// that only shows when it's flushed
+	if output != "" {
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/EconomistDigitalSolutions/ramlapi"
+	"github.com/buddhamagnet/raml"
+)
+
+// FormInfo describes the typed struct generated for a form body.
+type FormInfo struct {
+	Name, Verb, Path string
+	Fields           []*FormField
+}
+
+// FormField is a field of a form struct. Kind is the parameter's type:
+// string, integer, number, boolean, date or file.
+type FormField struct {
+	Name, Key, Kind, GoType, Parse string
+}
+
+// formFields maps parameter types to Go types, the expression parsing a
+// value s into one, and the package that needs importing.
+var formFields = map[string]struct{ goType, parse, pkg string }{
+	"string":  {"string", "", ""},
+	"integer": {"int64", "strconv.ParseInt(s, 10, 64)", "strconv"},
+	"number":  {"float64", "strconv.ParseFloat(s, 64)", "strconv"},
+	"boolean": {"bool", "strconv.ParseBool(s)", "strconv"},
+	"date":    {"time.Time", "http.ParseTime(s)", "time"},
+	"file":    {"*multipart.FileHeader", "", "mime/multipart"},
+}
+
+// forms lists the form bodies of an API's methods, and the packages their
+// structs need.
+func forms(api *raml.APIDefinition) ([]*FormInfo, []string) {
+	var list []*FormInfo
+	pkgs := make(map[string]bool)
+	var walk func(path string, resource *raml.Resource)
+	walk = func(path string, resource *raml.Resource) {
+		for _, method := range resource.Methods() {
+			if form := newFormInfo(path, method, api.MediaType, pkgs); form != nil {
+				list = append(list, form)
+			}
+		}
+		for _, nested := range sortedKeys(resource.Nested) {
+			walk(path+nested, resource.Nested[nested])
+		}
+	}
+	for _, path := range sortedKeys(api.Resources) {
+		resource := api.Resources[path]
+		walk(path, &resource)
+	}
+
+	var imports []string
+	for pkg := range pkgs {
+		if pkg != "" {
+			imports = append(imports, pkg)
+		}
+	}
+	sort.Strings(imports)
+	return list, imports
+}
+
+// newFormInfo returns the form struct for a method, or nil if it takes no
+// form parameters. Fields come from the URL-encoded and multipart bodies,
+// taking the first declaration of each name.
+func newFormInfo(path string, method *raml.Method, mediaType string, pkgs map[string]bool) *FormInfo {
+	form := &FormInfo{Name: ramlapi.DefaultNamer(path, method) + "Form", Verb: method.Name, Path: path}
+	seen := make(map[string]bool)
+	add := func(params map[string]raml.NamedParameter) {
+		for _, key := range sortedKeys(params) {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			kind := params[key].Type
+			if _, ok := formFields[kind]; !ok {
+				kind = "string"
+			}
+			f := formFields[kind]
+			pkgs[f.pkg] = true
+			form.Fields = append(form.Fields, &FormField{fieldName(key), key, kind, f.goType, f.parse})
+		}
+	}
+	if isForm(mediaType) {
+		add(method.Bodies.DefaultFormParameters)
+	}
+	for _, mediaType := range sortedKeys(method.Bodies.ForMIMEType) {
+		if isForm(mediaType) {
+			add(method.Bodies.ForMIMEType[mediaType].FormParameters)
+		}
+	}
+	if len(form.Fields) == 0 {
+		return nil
+	}
+	return form
+}
+
+func isForm(mediaType string) bool {
+	return mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data"
+}
+
+// fieldName turns a form parameter name into an exported Go identifier.
+func fieldName(key string) string {
+	name := ramlapi.Variableize(key)
+	if name == "" || !unicode.IsLetter(rune(name[0])) {
+		name = "F" + name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
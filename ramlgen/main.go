@@ -1,19 +1,24 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"log"
 	"os"
 	"os/exec"
+	"sort"
 	"text/template"
 
 	"github.com/EconomistDigitalSolutions/ramlapi"
+	"github.com/EconomistDigitalSolutions/ramlapi/openapi"
 	"github.com/buddhamagnet/raml"
 )
 
 var (
-	ramlFile string
-	genFile  string
+	ramlFile  string
+	genFile   string
+	testFile  string
+	outFormat string
 )
 
 // RouteMapEntry represents an entry in a route map.
@@ -27,19 +32,60 @@ type HandlerInfo struct {
 }
 
 func init() {
-	flag.StringVar(&ramlFile, "ramlfile", "api.raml", "RAML file to parse")
+	flag.StringVar(&ramlFile, "ramlfile", "api.raml", "RAML, OpenAPI 3 or Swagger 2 file to parse")
 	flag.StringVar(&genFile, "genfile", "handlers_gen.go", "Filename to use for output")
+	flag.StringVar(&testFile, "testfile", "", "Filename to use for conformance tests, if any")
+	flag.StringVar(&outFormat, "format", "go", "Output format: go for handlers, and tests if -testfile is set, json for a manifest of the endpoints")
 }
 
 func main() {
 	flag.Parse()
-	api, err := ramlapi.Process(ramlFile)
+	api, err := load(ramlFile)
 	if err != nil {
 		log.Fatal(err)
 	}
 	log.Println("Processing API spec for", ramlFile)
+	// Handlers and tests cover the methods resource types add, and the
+	// parameters traits add.
+	raml.Expand(api)
+	switch outFormat {
+	case "go":
+	case "json":
+		// The manifest goes to standard output unless a file is named
+		out := ""
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "genfile" {
+				out = genFile
+			}
+		})
+		if err := generateManifest(api, out); err != nil {
+			log.Fatal(err)
+		}
+		return
+	default:
+		log.Fatalf("unknown format %q", outFormat)
+	}
 	generate(api, genFile)
 	log.Println("Created handlers in ", genFile)
+	if testFile != "" {
+		if err := generateTests(api, testFile); err != nil {
+			log.Fatal(err)
+		}
+		log.Println("Created conformance tests in ", testFile)
+	}
+}
+
+// load parses an API spec. Files that don't start with a RAML version
+// comment are read as OpenAPI documents.
+func load(file string) (*raml.APIDefinition, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("#%RAML")) {
+		return ramlapi.Process(file)
+	}
+	return openapi.ParseFile(file)
 }
 
 // Generate handler functions based on an API definition.
@@ -51,7 +97,10 @@ func generate(api *raml.APIDefinition, genFile string) {
 	}
 	defer f.Close()
 	// Write the header - import statements and root handler.
-	f.WriteString(handlerHead)
+	formInfo, formImports := forms(api)
+	imports := append([]string{"encoding/json", "net/http"}, formImports...)
+	sort.Strings(imports)
+	template.Must(template.New("handlerHead").Parse(handlerHead)).Execute(f, imports)
 	// Start the route map (string to handler).
 	f.WriteString(mapStart)
 	// Add the route map entries.
@@ -66,9 +115,35 @@ func generate(api *raml.APIDefinition, genFile string) {
 	for name, resource := range api.Resources {
 		generateResource("", name, &resource, t, f)
 	}
+	// And typed structs for form bodies.
+	ft := template.Must(template.New("formText").Parse(formText))
+	for _, form := range formInfo {
+		if err := ft.Execute(f, form); err != nil {
+			log.Println("executing template:", err)
+		}
+	}
 	format(f)
 }
 
+// generateManifest writes the endpoints of an API as JSON to a file, or
+// to standard output if the file name is empty.
+func generateManifest(api *raml.APIDefinition, file string) error {
+	b, err := ramlapi.Manifest(api)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if file == "" {
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+	if err := os.WriteFile(file, b, 0644); err != nil {
+		return err
+	}
+	log.Println("Created manifest in ", file)
+	return nil
+}
+
 // format runs go fmt on a file.
 func format(f *os.File) {
 	// Run go fmt on the file.
@@ -83,7 +158,7 @@ func generateResource(parent, name string, resource *raml.Resource, t *template.
 	path := parent + name
 
 	for _, method := range resource.Methods() {
-		err := t.Execute(f, HandlerInfo{ramlapi.Variableize(method.DisplayName), method.Name, path, method.Description})
+		err := t.Execute(f, HandlerInfo{ramlapi.DefaultNamer(path, method), method.Name, path, method.Description})
 		if err != nil {
 			log.Println("executing template:", err)
 		}
@@ -91,7 +166,7 @@ func generateResource(parent, name string, resource *raml.Resource, t *template.
 
 	// Get all children.
 	for nestname, nested := range resource.Nested {
-		return generateResource(path, nestname, nested, t, f)
+		generateResource(path, nestname, nested, t, f)
 	}
 	return path
 }
@@ -103,7 +178,7 @@ func generateMap(parent, name string, resource *raml.Resource, e *template.Templ
 	path := parent + name
 
 	for _, method := range resource.Methods() {
-		name := ramlapi.Variableize(method.DisplayName)
+		name := ramlapi.DefaultNamer(path, method)
 		err := e.Execute(f, RouteMapEntry{name, name})
 		if err != nil {
 			log.Println("executing template:", err)
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/EconomistDigitalSolutions/ramlapi"
+	"github.com/buddhamagnet/raml"
 )
 
 var output = "/%s/test_gen_%d"
@@ -21,3 +26,116 @@ func TestGenerate(t *testing.T) {
 	}
 	os.Remove(currentOutput)
 }
+
+func TestLoad(t *testing.T) {
+	for _, file := range []string{"../fixtures/valid.raml", "../fixtures/swagger.json"} {
+		api, err := load(file)
+		if err != nil {
+			t.Fatalf("%s: %s", file, err)
+		}
+		if len(api.Resources) == 0 {
+			t.Errorf("%s: expected resources", file)
+		}
+	}
+}
+
+func TestGenerateTests(t *testing.T) {
+	api, err := ramlapi.Process("../fixtures/openapi.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raml.Expand(api)
+	dir := t.TempDir()
+	generate(api, filepath.Join(dir, "handlers_gen.go"))
+	if err := generateTests(api, filepath.Join(dir, "handlers_gen_test.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "handlers_gen_test.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"func TestGetBooksConformance(t *testing.T) {",
+		`target:      "/books?page=0",`,
+		`body:        "{\"id\": 1, \"title\": \"Ulysses\"}\n",`,
+		`name:        "invalid URI parameter isbn (pattern)",`,
+		`headers:     map[string]string{"Accept-Language": "not-en"},`,
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("expected tests to contain %q, got:\n%s", want, b)
+		}
+	}
+
+	// The generated code must compile against the generated handlers
+	vet(t, dir)
+}
+
+func TestGenerateForms(t *testing.T) {
+	api, err := ramlapi.Process("../fixtures/forms.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	generate(api, filepath.Join(dir, "handlers_gen.go"))
+	if err := generateTests(api, filepath.Join(dir, "handlers_gen_test.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "handlers_gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := strings.Join(strings.Fields(string(b)), " ")
+	for _, want := range []string{
+		`"mime/multipart"`,
+		"type UploadPhotoForm struct {",
+		"File *multipart.FileHeader `form:\"file\"`",
+		"Rating int64 `form:\"rating\"`",
+		"TakenAt time.Time `form:\"taken-at\"`",
+		"Url string `form:\"url\"`",
+		"func ParseUploadPhotoForm(r *http.Request) (*UploadPhotoForm, error) {",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("expected handlers to contain %q, got:\n%s", want, b)
+		}
+	}
+	vet(t, dir)
+}
+
+// vet checks the code generated in dir compiles.
+func vet(t *testing.T, dir string) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module conformance\n"), 0644)
+	cmd := exec.Command(goTool, "vet", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GO111MODULE=on", "GOFLAGS=")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("generated code doesn't vet: %s\n%s", err, out)
+	}
+}
+
+func TestGenerateManifest(t *testing.T) {
+	api, err := ramlapi.Process("../fixtures/valid.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "endpoints.json")
+	if err := generateManifest(api, file); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var endpoints []*ramlapi.ManifestEndpoint
+	if err := json.Unmarshal(b, &endpoints); err != nil {
+		t.Fatal(err)
+	}
+	if len(endpoints) == 0 || endpoints[0].Endpoint == nil || endpoints[0].Handler == "" {
+		t.Errorf("expected endpoints, got %s", b)
+	}
+}
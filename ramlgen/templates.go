@@ -3,8 +3,9 @@ package main
 const handlerHead = `package main
 
 import (
-	"encoding/json"
-	"net/http"
+{{- range .}}
+	"{{.}}"
+{{- end}}
 )
 `
 
@@ -30,3 +31,120 @@ func {{.Name}}(w http.ResponseWriter, r *http.Request) {
  	w.Write(json)
 }
 `
+
+const testHead = `package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// conformanceCase is a request to a handler and the status codes the spec
+// allows in response. Invalid requests must get a client error, one of
+// codes if any are given.
+type conformanceCase struct {
+	name, verb, target, contentType, body string
+	headers                               map[string]string
+	codes                                 []int
+	clientError                           bool
+}
+
+func runConformance(t *testing.T, handler http.HandlerFunc, cases []conformanceCase) {
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(c.verb, c.target, strings.NewReader(c.body))
+			if c.contentType != "" {
+				r.Header.Set("Content-Type", c.contentType)
+			}
+			for k, v := range c.headers {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			declared := len(c.codes) == 0
+			for _, code := range c.codes {
+				declared = declared || code == w.Code
+			}
+			switch {
+			case !declared:
+				t.Errorf("%s %s: expected one of %v, got %d", c.verb, c.target, c.codes, w.Code)
+			case c.clientError && (w.Code < 400 || w.Code > 499):
+				t.Errorf("%s %s: expected a client error, got %d", c.verb, c.target, w.Code)
+			case w.Code >= 500:
+				t.Errorf("%s %s: expected no server error, got %d", c.verb, c.target, w.Code)
+			}
+		})
+	}
+}
+`
+
+const testText = `
+// Test{{.Handler}}Conformance checks {{.Handler}} against the spec.
+func Test{{.Handler}}Conformance(t *testing.T) {
+	runConformance(t, {{.Handler}}, []conformanceCase{
+{{- range .Cases}}
+		{
+			name: {{printf "%q" .Name}},
+			verb: {{printf "%q" .Verb}},
+			target: {{printf "%q" .Target}},
+{{- if .ContentType}}
+			contentType: {{printf "%q" .ContentType}},
+			body: {{printf "%q" .Body}},
+{{- end}}
+{{- if .Headers}}
+			headers: map[string]string{ {{- range $k, $v := .Headers}}{{printf "%q" $k}}: {{printf "%q" $v}}, {{end -}} },
+{{- end}}
+{{- if .Codes}}
+			codes: []int{ {{- range .Codes}}{{.}}, {{end -}} },
+{{- end}}
+{{- if .ClientError}}
+			clientError: true,
+{{- end}}
+		},
+{{- end}}
+	})
+}
+`
+
+const formText = `
+// {{.Name}} is the form body of {{.Verb}} {{.Path}}.
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.GoType}} ` + "`" + `form:"{{.Key}}"` + "`" + `
+{{- end}}
+}
+
+// Parse{{.Name}} reads the form from a URL-encoded or multipart request
+// body. Missing parameters are left at their zero value.
+func Parse{{.Name}}(r *http.Request) (*{{.Name}}, error) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil && err != http.ErrNotMultipart {
+		return nil, err
+	}
+	form := &{{.Name}}{}
+{{- range .Fields}}
+{{- if eq .Kind "file"}}
+	if file, header, err := r.FormFile("{{.Key}}"); err == nil {
+		file.Close()
+		form.{{.Name}} = header
+	} else if err != http.ErrMissingFile && err != http.ErrNotMultipart {
+		return nil, err
+	}
+{{- else if eq .Kind "string"}}
+	form.{{.Name}} = r.PostFormValue("{{.Key}}")
+{{- else}}
+	if s := r.PostFormValue("{{.Key}}"); s != "" {
+		v, err := {{.Parse}}
+		if err != nil {
+			return nil, err
+		}
+		form.{{.Name}} = v
+	}
+{{- end}}
+{{- end}}
+	return form, nil
+}
+`
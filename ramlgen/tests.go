@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	gofmt "go/format"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"text/template"
+
+	"github.com/EconomistDigitalSolutions/ramlapi"
+	"github.com/buddhamagnet/raml"
+)
+
+var placeholderPattern = regexp.MustCompile(`{([^}]+)}`)
+
+// TestInfo contains the conformance test cases for a handler.
+type TestInfo struct {
+	Handler string
+	Cases   []*TestCase
+}
+
+// TestCase is a request to a handler and the status codes the spec allows
+// in response. Invalid requests must get a client error, one of the
+// declared 4xx codes if there are any.
+type TestCase struct {
+	Name, Verb, Target, ContentType, Body string
+	Headers                               map[string]string
+	Codes                                 []int
+	ClientError                           bool
+}
+
+// generateTests writes a test file that checks each generated handler
+// against the spec. Requests are built from the examples and constraints
+// of the parameters: a valid request, then requests missing each required
+// parameter and breaking each constraint.
+func generateTests(api *raml.APIDefinition, testFile string) error {
+	var buf bytes.Buffer
+	buf.WriteString(testHead)
+	t := template.Must(template.New("testText").Parse(testText))
+	for _, path := range sortedKeys(api.Resources) {
+		resource := api.Resources[path]
+		if err := generateResourceTests(path, &resource, nil, t, &buf); err != nil {
+			return err
+		}
+	}
+
+	src, err := gofmt.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(testFile, src, 0644)
+}
+
+func generateResourceTests(path string, resource *raml.Resource, uriParams map[string]raml.NamedParameter, t *template.Template, buf *bytes.Buffer) error {
+	params := make(map[string]raml.NamedParameter)
+	for name, p := range uriParams {
+		params[name] = p
+	}
+	for name, p := range resource.UriParameters {
+		params[name] = p
+	}
+
+	for _, method := range resource.Methods() {
+		info := TestInfo{ramlapi.DefaultNamer(path, method), testCases(path, method, params)}
+		if err := t.Execute(buf, info); err != nil {
+			return err
+		}
+	}
+
+	for _, nested := range sortedKeys(resource.Nested) {
+		if err := generateResourceTests(path+nested, resource.Nested[nested], params, t, buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// request holds the parts of a test request as it is built.
+type request struct {
+	uri     map[string]string
+	query   url.Values
+	headers map[string]string
+	form    url.Values
+}
+
+func testCases(path string, method *raml.Method, uriParams map[string]raml.NamedParameter) []*TestCase {
+	var codes, clientCodes []int
+	for code := range method.Responses {
+		codes = append(codes, int(code))
+		if code >= 400 && code < 500 {
+			clientCodes = append(clientCodes, int(code))
+		}
+	}
+	sort.Ints(codes)
+	sort.Ints(clientCodes)
+
+	// The valid request sets every URI parameter and every required
+	// parameter.
+	valid := &request{uri: make(map[string]string), query: url.Values{}, headers: make(map[string]string), form: url.Values{}}
+	for _, name := range uriNames(path) {
+		valid.uri[name], _ = ramlapi.SampleValue(uriParams[name])
+	}
+	for name, p := range method.QueryParameters {
+		if p.Required {
+			valid.query.Set(name, sample(p))
+		}
+	}
+	for name, h := range method.Headers {
+		if h.Required {
+			valid.headers[string(name)] = sample(raml.NamedParameter(h))
+		}
+	}
+	mediaType, body := requestBody(method.Bodies)
+	for name, p := range body.FormParameters {
+		if p.Required {
+			valid.form.Set(name, sample(p))
+		}
+	}
+
+	cases := []*TestCase{newTestCase("valid", path, method.Name, mediaType, body, valid, codes, false)}
+	invalid := func(name string, change func(r *request)) {
+		r := valid.clone()
+		change(r)
+		cases = append(cases, newTestCase(name, path, method.Name, mediaType, body, r, clientCodes, true))
+	}
+
+	for _, name := range uriNames(path) {
+		for _, v := range ramlapi.InvalidValues(uriParams[name]) {
+			name, v := name, v
+			invalid("invalid URI parameter "+name+" ("+v.Reason+")", func(r *request) { r.uri[name] = v.Value })
+		}
+	}
+	for _, name := range sortedKeys(method.QueryParameters) {
+		name, p := name, method.QueryParameters[name]
+		if p.Required {
+			invalid("missing query parameter "+name, func(r *request) { r.query.Del(name) })
+		}
+		for _, v := range ramlapi.InvalidValues(p) {
+			v := v
+			invalid("invalid query parameter "+name+" ("+v.Reason+")", func(r *request) { r.query.Set(name, v.Value) })
+		}
+	}
+	for _, name := range sortedHeaders(method.Headers) {
+		name, p := name, raml.NamedParameter(method.Headers[raml.HTTPHeader(name)])
+		if p.Required {
+			invalid("missing header "+name, func(r *request) { delete(r.headers, name) })
+		}
+		for _, v := range ramlapi.InvalidValues(p) {
+			v := v
+			invalid("invalid header "+name+" ("+v.Reason+")", func(r *request) { r.headers[name] = v.Value })
+		}
+	}
+	for _, name := range sortedKeys(body.FormParameters) {
+		name, p := name, body.FormParameters[name]
+		if p.Required {
+			invalid("missing form parameter "+name, func(r *request) { r.form.Del(name) })
+		}
+		for _, v := range ramlapi.InvalidValues(p) {
+			v := v
+			invalid("invalid form parameter "+name+" ("+v.Reason+")", func(r *request) { r.form.Set(name, v.Value) })
+		}
+	}
+
+	return cases
+}
+
+func newTestCase(name, path, verb, mediaType string, body raml.Body, r *request, codes []int, clientError bool) *TestCase {
+	target := placeholderPattern.ReplaceAllStringFunc(path, func(p string) string {
+		return url.PathEscape(r.uri[p[1:len(p)-1]])
+	})
+	if len(r.query) > 0 {
+		target += "?" + r.query.Encode()
+	}
+
+	c := &TestCase{
+		Name:        name,
+		Verb:        verb,
+		Target:      target,
+		Headers:     r.headers,
+		Codes:       codes,
+		ClientError: clientError,
+	}
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		c.ContentType, c.Body = mediaType, r.form.Encode()
+	case mediaType == "multipart/form-data":
+		c.ContentType, c.Body = multipartBody(r.form, body.FormParameters)
+	case body.Example != "":
+		c.ContentType, c.Body = mediaType, body.Example
+	}
+	return c
+}
+
+// requestBody picks the body to send: the first form, or the first body
+// with an example.
+func requestBody(bodies raml.Bodies) (string, raml.Body) {
+	for _, mediaType := range sortedKeys(bodies.ForMIMEType) {
+		if mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data" {
+			return mediaType, bodies.ForMIMEType[mediaType]
+		}
+	}
+	for _, mediaType := range sortedKeys(bodies.ForMIMEType) {
+		if bodies.ForMIMEType[mediaType].Example != "" {
+			return mediaType, bodies.ForMIMEType[mediaType]
+		}
+	}
+	if bodies.DefaultExample != "" {
+		return "application/json", raml.Body{Example: bodies.DefaultExample}
+	}
+	return "", raml.Body{}
+}
+
+// multipartBody encodes a form, sending file parameters as files.
+func multipartBody(form url.Values, params map[string]raml.NamedParameter) (string, string) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	w.SetBoundary("conformance")
+	for _, name := range sortedKeys(form) {
+		if params[name].Type == "file" {
+			part, _ := w.CreateFormFile(name, name+".txt")
+			part.Write([]byte(form.Get(name)))
+			continue
+		}
+		w.WriteField(name, form.Get(name))
+	}
+	w.Close()
+	return w.FormDataContentType(), buf.String()
+}
+
+func (r *request) clone() *request {
+	c := &request{uri: make(map[string]string), query: url.Values{}, headers: make(map[string]string), form: url.Values{}}
+	for k, v := range r.uri {
+		c.uri[k] = v
+	}
+	for k, v := range r.query {
+		c.query[k] = append([]string(nil), v...)
+	}
+	for k, v := range r.headers {
+		c.headers[k] = v
+	}
+	for k, v := range r.form {
+		c.form[k] = append([]string(nil), v...)
+	}
+	return c
+}
+
+// sample returns a valid value for a parameter, or an empty string if it
+// has none.
+func sample(p raml.NamedParameter) string {
+	v, _ := ramlapi.SampleValue(p)
+	return v
+}
+
+func uriNames(path string) []string {
+	var names []string
+	for _, m := range placeholderPattern.FindAllStringSubmatch(path, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedHeaders(headers map[raml.HTTPHeader]raml.Header) []string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	return names
+}
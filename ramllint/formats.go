@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// formats maps output format names to writers.
+var formats = map[string]func(w io.Writer, findings []*Finding) error{
+	"human":      writeHuman,
+	"json":       writeJSON,
+	"checkstyle": writeCheckstyle,
+	"sarif":      writeSARIF,
+}
+
+func writeHuman(w io.Writer, findings []*Finding) error {
+	for _, f := range findings {
+		if _, err := fmt.Fprintf(w, "%s: %s [%s]\n", f.Severity, f.Problem, f.Rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, findings []*Finding) error {
+	type jsonFinding struct {
+		Rule     string `json:"rule"`
+		Severity string `json:"severity"`
+		File     string `json:"file,omitempty"`
+		Line     int    `json:"line,omitempty"`
+		Column   int    `json:"column,omitempty"`
+		Path     string `json:"path,omitempty"`
+		Message  string `json:"message"`
+	}
+	out := make([]jsonFinding, 0, len(findings))
+	for _, f := range findings {
+		out = append(out, jsonFinding{f.Rule, f.Severity, f.File, f.Line, f.Column, strings.Join(f.Path, " "), f.Message})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// writeCheckstyle writes the findings as Checkstyle XML, grouped by file.
+func writeCheckstyle(w io.Writer, findings []*Finding) error {
+	type checkstyleError struct {
+		Line     int    `xml:"line,attr"`
+		Column   int    `xml:"column,attr,omitempty"`
+		Severity string `xml:"severity,attr"`
+		Message  string `xml:"message,attr"`
+		Source   string `xml:"source,attr"`
+	}
+	type checkstyleFile struct {
+		Name   string            `xml:"name,attr"`
+		Errors []checkstyleError `xml:"error"`
+	}
+	type checkstyle struct {
+		XMLName xml.Name          `xml:"checkstyle"`
+		Version string            `xml:"version,attr"`
+		Files   []*checkstyleFile `xml:"file"`
+	}
+
+	out := checkstyle{Version: "4.3"}
+	files := make(map[string]*checkstyleFile)
+	for _, f := range findings {
+		file, ok := files[f.File]
+		if !ok {
+			file = &checkstyleFile{Name: f.File}
+			files[f.File] = file
+			out.Files = append(out.Files, file)
+		}
+		file.Errors = append(file.Errors, checkstyleError{
+			Line:     f.Line,
+			Column:   f.Column,
+			Severity: f.Severity,
+			Message:  message(f),
+			Source:   "ramllint." + f.Rule,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// writeSARIF writes the findings as a SARIF 2.1.0 log.
+func writeSARIF(w io.Writer, findings []*Finding) error {
+	type object = map[string]interface{}
+
+	rules := make([]object, 0, len(Rules))
+	for _, r := range Rules {
+		rules = append(rules, object{
+			"id":               r.Name,
+			"shortDescription": object{"text": r.Description},
+		})
+	}
+
+	results := make([]object, 0, len(findings))
+	for _, f := range findings {
+		level := "error"
+		if f.Severity == SeverityWarning {
+			level = "warning"
+		}
+		result := object{
+			"ruleId":  f.Rule,
+			"level":   level,
+			"message": object{"text": message(f)},
+		}
+		if f.File != "" {
+			location := object{"artifactLocation": object{"uri": f.File}}
+			if f.Line != 0 {
+				// SARIF columns are 1-based, so an unknown one is the first
+				location["region"] = object{"startLine": f.Line, "startColumn": max(f.Column, 1)}
+			}
+			result["locations"] = []object{{"physicalLocation": location}}
+		}
+		results = append(results, result)
+	}
+
+	log := object{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []object{{
+			"tool":    object{"driver": object{"name": "raml-lint", "rules": rules}},
+			"results": results,
+		}},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+// message returns a finding's message, prefixed with its RAML path.
+func message(f *Finding) string {
+	if len(f.Path) == 0 {
+		return f.Message
+	}
+	return strings.Join(f.Path, " ") + ": " + f.Message
+}
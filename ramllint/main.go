@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/EconomistDigitalSolutions/ramlapi"
+)
+
+var (
+	ramlFile   string
+	configFile string
+	format     string
+)
+
+// Config sets the severity of each rule. Rules not mentioned are errors.
+//
+//	{"rules": {"method-description": "warning", "kebab-case-paths": "off"}}
+type Config struct {
+	Rules map[string]string `json:"rules"`
+}
+
+func (c *Config) severity(rule string) string {
+	if s, ok := c.Rules[rule]; ok {
+		return s
+	}
+	return SeverityError
+}
+
+func init() {
+	flag.StringVar(&ramlFile, "ramlfile", "api.raml", "RAML file to lint")
+	flag.StringVar(&configFile, "config", "", "JSON file setting the severity of each rule")
+	flag.StringVar(&format, "format", "human", "Output format: human, json, checkstyle or sarif")
+}
+
+func main() {
+	flag.Parse()
+	config, err := loadConfig(configFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	write, ok := formats[format]
+	if !ok {
+		log.Fatalf("unknown format %q", format)
+	}
+
+	api, err := ramlapi.Process(ramlFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	findings := lint(api, config)
+	if err := write(os.Stdout, findings); err != nil {
+		log.Fatal(err)
+	}
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			os.Exit(1)
+		}
+	}
+}
+
+// loadConfig reads a rule configuration file. An empty name gives the
+// default configuration.
+func loadConfig(name string) (*Config, error) {
+	config := &Config{}
+	if name == "" {
+		return config, nil
+	}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, config); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %s", name, err)
+	}
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %s", name, err)
+	}
+	return config, nil
+}
+
+// validate checks that the configured rules exist, so a typo doesn't turn
+// a rule off, and that their severities are valid.
+func (c *Config) validate() error {
+	known := make(map[string]bool)
+	for _, rule := range Rules {
+		known[rule.Name] = true
+	}
+	for _, rule := range sortedKeys(c.Rules) {
+		if !known[rule] {
+			return fmt.Errorf("unknown rule %q", rule)
+		}
+		if severity := c.Rules[rule]; severity != SeverityError && severity != SeverityWarning && severity != SeverityOff {
+			return fmt.Errorf("invalid severity %q for rule %s", severity, rule)
+		}
+	}
+	return nil
+}
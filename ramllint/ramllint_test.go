@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/EconomistDigitalSolutions/ramlapi"
+	"github.com/buddhamagnet/raml"
+)
+
+func TestLint(t *testing.T) {
+	api, err := ramlapi.Process("../fixtures/lint.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &Config{Rules: map[string]string{
+		"method-description": SeverityWarning,
+		"response-example":   SeverityOff,
+	}}
+
+	var got []string
+	for _, f := range lint(api, config) {
+		got = append(got, f.Severity+" "+f.Rule+" "+f.Problem.String())
+	}
+	expected := []string{
+		`error method-display-name ../fixtures/lint.raml:16:3: /userAccounts/{id} delete: DELETE has no displayName`,
+		`warning method-description ../fixtures/lint.raml:16:3: /userAccounts/{id} delete: DELETE has no description`,
+		`error success-body ../fixtures/lint.raml:14:7: /userAccounts/{id} get responses 201: 201 response declares no body`,
+		`error kebab-case-paths ../fixtures/lint.raml:4:1: /userAccounts/{id}: path segment "userAccounts" is not kebab-case`,
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected findings:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestLintExpanded(t *testing.T) {
+	api, err := ramlapi.ProcessBytes([]byte(`#%RAML 0.8
+title: Inherited
+resourceTypes:
+  - collection:
+      get:
+        description: Lists <<resourcePathName>>.
+traits:
+  - deletable:
+      description: Deletes the item.
+/items:
+  type: collection
+  /{id}:
+    delete:
+      is: [deletable]
+    put:
+      displayName: Replace item
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &Config{Rules: map[string]string{}}
+	for _, rule := range Rules {
+		config.Rules[rule.Name] = SeverityOff
+	}
+	config.Rules["method-description"] = SeverityError
+
+	var got []string
+	for _, f := range lint(api, config) {
+		got = append(got, f.Problem.String())
+	}
+	// Descriptions from the resource type and trait count
+	expected := []string{`15:5: /items /{id} put: PUT has no description`}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected findings:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+	if get := api.Resources["/items"].Get; get != nil {
+		t.Errorf("expected the API not to be expanded, got %+v", get)
+	}
+}
+
+func TestFormats(t *testing.T) {
+	api, err := ramlapi.Process("../fixtures/lint.raml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := lint(api, &Config{})
+
+	for name, write := range formats {
+		var buf bytes.Buffer
+		if err := write(&buf, findings); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		var v interface{}
+		switch name {
+		case "json", "sarif":
+			err = json.Unmarshal(buf.Bytes(), &v)
+		case "checkstyle":
+			err = xml.Unmarshal(buf.Bytes(), &struct{}{})
+		}
+		if err != nil {
+			t.Errorf("%s output is malformed: %v", name, err)
+		}
+		if !strings.Contains(buf.String(), "kebab-case") {
+			t.Errorf("%s output is missing findings:\n%s", name, buf.String())
+		}
+	}
+}
+
+func TestConfig(t *testing.T) {
+	for _, test := range []struct {
+		config *Config
+		err    string
+	}{
+		{&Config{Rules: map[string]string{"method-description": SeverityOff}}, ""},
+		{&Config{Rules: map[string]string{"method-descripton": SeverityOff}}, `unknown rule "method-descripton"`},
+		{&Config{Rules: map[string]string{"method-description": "fatal"}}, `invalid severity "fatal" for rule method-description`},
+	} {
+		err := test.config.validate()
+		if (err == nil && test.err != "") || (err != nil && err.Error() != test.err) {
+			t.Errorf("%v: expected error %q, got %v", test.config.Rules, test.err, err)
+		}
+	}
+}
+
+func TestSARIFColumn(t *testing.T) {
+	findings := []*Finding{{"kebab-case-paths", SeverityError, &raml.Problem{File: "api.raml", Line: 3, Message: "bad"}}}
+	var buf bytes.Buffer
+	if err := formats["sarif"](&buf, findings); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"startColumn": 1`) {
+		t.Errorf("expected an unknown column to be given as 1, got\n%s", buf.String())
+	}
+}
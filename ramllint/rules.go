@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/buddhamagnet/raml"
+)
+
+var kebabCase = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Severities a rule may be configured with.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityOff     = "off"
+)
+
+// Finding is a rule violation.
+type Finding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	*raml.Problem
+}
+
+// Rule is a team convention checked against an API.
+type Rule struct {
+	Name        string
+	Description string
+	Check       func(api *raml.APIDefinition) []*raml.Problem
+
+	// Raw rules check the API as written. Others check it with resource
+	// types and traits applied, so inherited properties count.
+	Raw bool
+}
+
+// resource is a resource found while walking an API, with the keys
+// leading to it and its full URI.
+type resource struct {
+	path     []string
+	uri      string
+	resource *raml.Resource
+}
+
+// reporter reports a problem with the node at path.
+type reporter func(path []string, format string, args ...interface{})
+
+// Rules lists the available rules.
+var Rules = []Rule{
+	{
+		Name:        "valid-raml",
+		Description: "The RAML passes semantic validation.",
+		Check: func(api *raml.APIDefinition) []*raml.Problem {
+			if err, ok := raml.Validate(api).(*raml.RamlError); ok {
+				return err.Problems
+			}
+			return nil
+		},
+		Raw: true,
+	},
+	{
+		Name:        "method-display-name",
+		Description: "Every method has a displayName.",
+		Check: forEachMethod(func(path []string, m *raml.Method, report reporter) {
+			if m.DisplayName == "" {
+				report(path, "%s has no displayName", m.Name)
+			}
+		}),
+	},
+	{
+		Name:        "method-description",
+		Description: "Every method has a description.",
+		Check: forEachMethod(func(path []string, m *raml.Method, report reporter) {
+			if strings.TrimSpace(m.Description) == "" {
+				report(path, "%s has no description", m.Name)
+			}
+		}),
+	},
+	{
+		Name:        "response-example",
+		Description: "Every response body has an example.",
+		Check: forEachMethod(func(path []string, m *raml.Method, report reporter) {
+			for _, code := range sortedCodes(m.Responses) {
+				bodies := m.Responses[code].Bodies
+				for _, mediaType := range sortedKeys(bodies.ForMIMEType) {
+					if bodies.ForMIMEType[mediaType].Example == "" && bodies.DefaultExample == "" {
+						report(join(path, "responses", strconv.Itoa(int(code)), "body", mediaType),
+							"%d %s response has no example", code, mediaType)
+					}
+				}
+			}
+		}),
+	},
+	{
+		Name:        "success-body",
+		Description: "Every 2xx response other than 204 declares a body.",
+		Check: forEachMethod(func(path []string, m *raml.Method, report reporter) {
+			for _, code := range sortedCodes(m.Responses) {
+				if code < 200 || code > 299 || code == 204 {
+					continue
+				}
+				if len(m.Responses[code].Bodies.ForMIMEType) == 0 {
+					report(join(path, "responses", strconv.Itoa(int(code))),
+						"%d response declares no body", code)
+				}
+			}
+		}),
+	},
+	{
+		Name:        "kebab-case-paths",
+		Description: "Path segments are kebab-case.",
+		Check: forEachResource(func(r *resource, report reporter) {
+			key := r.path[len(r.path)-1]
+			for _, segment := range strings.Split(strings.Trim(key, "/"), "/") {
+				if segment == "" || strings.HasPrefix(segment, "{") {
+					continue
+				}
+				if !kebabCase.MatchString(segment) {
+					report(r.path, "path segment %q is not kebab-case", segment)
+				}
+			}
+		}),
+	},
+}
+
+// forEachResource adapts a resource check into a rule check.
+func forEachResource(check func(r *resource, report reporter)) func(*raml.APIDefinition) []*raml.Problem {
+	return func(api *raml.APIDefinition) []*raml.Problem {
+		var problems []*raml.Problem
+		report := func(path []string, format string, args ...interface{}) {
+			problems = append(problems, api.NewProblem(path, fmt.Sprintf(format, args...)))
+		}
+		walk(api, func(r *resource) {
+			check(r, report)
+		})
+		return problems
+	}
+}
+
+// forEachMethod adapts a method check into a rule check.
+func forEachMethod(check func(path []string, m *raml.Method, report reporter)) func(*raml.APIDefinition) []*raml.Problem {
+	return forEachResource(func(r *resource, report reporter) {
+		for _, m := range r.resource.Methods() {
+			check(join(r.path, strings.ToLower(m.Name)), m, report)
+		}
+	})
+}
+
+// lint checks an API against the rules enabled in config.
+func lint(api *raml.APIDefinition, config *Config) []*Finding {
+	// The copy shares the positions of the original, so problems found in
+	// it are still located
+	expanded := raml.Expanded(api)
+
+	var findings []*Finding
+	for _, rule := range Rules {
+		severity := config.severity(rule.Name)
+		if severity == SeverityOff {
+			continue
+		}
+		checked := expanded
+		if rule.Raw {
+			checked = api
+		}
+		for _, p := range rule.Check(checked) {
+			findings = append(findings, &Finding{rule.Name, severity, p})
+		}
+	}
+	return findings
+}
+
+// walk calls fn for every resource in an API, in path order.
+func walk(api *raml.APIDefinition, fn func(r *resource)) {
+	for _, uri := range sortedKeys(api.Resources) {
+		res := api.Resources[uri]
+		walkResource([]string{uri}, uri, &res, fn)
+	}
+}
+
+func walkResource(path []string, uri string, r *raml.Resource, fn func(r *resource)) {
+	fn(&resource{path, uri, r})
+	for _, nested := range sortedKeys(r.Nested) {
+		walkResource(join(path, nested), uri+nested, r.Nested[nested], fn)
+	}
+}
+
+func join(path []string, elems ...string) []string {
+	return append(append([]string(nil), path...), elems...)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedCodes(responses map[raml.HTTPCode]raml.Response) []raml.HTTPCode {
+	codes := make([]raml.HTTPCode, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
@@ -0,0 +1,144 @@
+package ramlapi
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/buddhamagnet/raml"
+)
+
+// Reloader serves requests with a handler built from a RAML file, and
+// rebuilds it when the file or any file it includes changes. It is an
+// http.Handler, so it can stand in for the router built by Build.
+type Reloader struct {
+	file  string
+	build func(*raml.APIDefinition) (http.Handler, error)
+
+	handler atomic.Value // of reloaded
+
+	// mu guards stamps, the modification times and sizes of the spec's
+	// files when last loaded.
+	mu     sync.Mutex
+	stamps map[string]stamp
+}
+
+type reloaded struct {
+	api     *raml.APIDefinition
+	handler http.Handler
+}
+
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewReloader parses and validates the RAML file, then serves requests
+// with the handler build makes from it, typically a router set up with
+// Build. It returns an error if the spec is invalid or build fails.
+func NewReloader(file string, build func(api *raml.APIDefinition) (http.Handler, error)) (*Reloader, error) {
+	r := &Reloader{file: file, build: build}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Reloader) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.handler.Load().(*reloaded).handler.ServeHTTP(w, req)
+}
+
+// API returns the definition currently being served.
+func (r *Reloader) API() *raml.APIDefinition {
+	return r.handler.Load().(*reloaded).api
+}
+
+// Reload parses and validates the spec again and swaps in a handler built
+// from it. If the spec is invalid or build fails the current handler is
+// kept, and the error returned.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Note the files as they are now, so a broken spec isn't reloaded
+	// until it changes again
+	files := []string{r.file}
+	for name := range r.stamps {
+		files = append(files, name)
+	}
+	r.stamps = stamps(files)
+
+	api, err := Process(r.file)
+	if err != nil {
+		return err
+	}
+	if err := Validate(api); err != nil {
+		return err
+	}
+	handler, err := r.build(api)
+	if err != nil {
+		return err
+	}
+	r.handler.Store(&reloaded{api, handler})
+	r.stamps = stamps(api.Files())
+	return nil
+}
+
+// Changed reports whether the spec or any file it includes has been
+// modified, created or removed since it was last loaded.
+func (r *Reloader) Changed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, s := range r.stamps {
+		if statFile(name) != s {
+			return true
+		}
+	}
+	return false
+}
+
+// Watch polls the spec's files every interval until ctx is done,
+// reloading when they change. Errors from Reload are passed to onError, or
+// logged if it is nil.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	if onError == nil {
+		onError = func(err error) { log.Println("reloading", r.file, err) }
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if r.Changed() {
+				if err := r.Reload(); err != nil {
+					onError(err)
+				}
+			}
+		}
+	}
+}
+
+func stamps(files []string) map[string]stamp {
+	m := make(map[string]stamp, len(files))
+	for _, name := range files {
+		m[name] = statFile(name)
+	}
+	return m
+}
+
+// statFile returns the stamp of a file, or the zero stamp if it doesn't
+// exist.
+func statFile(name string) stamp {
+	info, err := os.Stat(filepath.FromSlash(name))
+	if err != nil {
+		return stamp{}
+	}
+	return stamp{info.ModTime(), info.Size()}
+}
@@ -0,0 +1,186 @@
+package ramlapi
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/buddhamagnet/raml"
+)
+
+// RequestError lists the ways a request breaks its endpoint's spec.
+type RequestError struct {
+	Problems []string
+}
+
+func (e *RequestError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// CheckRequest checks a request against an endpoint: its URI parameters,
+// when the request path matches the endpoint's, its query parameters, its
+// headers, which are matched case-insensitively, and the parameters of
+// URL-encoded and multipart form bodies, where file parameters must be
+// sent as files. Required parameters must be present and every value must
+// meet its constraints. The form is parsed, so handlers can read it from
+// the request as usual. It returns a *RequestError listing the problems,
+// or nil if there are none.
+func CheckRequest(ep *Endpoint, r *http.Request) error {
+	return newRequestChecker(ep).check(r)
+}
+
+// ValidateRequest checks each request with CheckRequest before calling
+// next, answering 400 with a line per problem if the request is invalid.
+func ValidateRequest(ep *Endpoint, next http.Handler) http.Handler {
+	c := newRequestChecker(ep)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := c.check(r); err != nil {
+			http.Error(w, strings.Join(err.(*RequestError).Problems, "\n"), http.StatusBadRequest)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// maxFormMemory is how much of a multipart form is held in memory, the
+// rest of its files being stored on disk.
+const maxFormMemory = 32 << 20
+
+type requestChecker struct {
+	ep *Endpoint
+
+	// path matches the end of a request path, capturing the URI
+	// parameters named in names.
+	path  *regexp.Regexp
+	names []string
+}
+
+func newRequestChecker(ep *Endpoint) *requestChecker {
+	c := &requestChecker{ep: ep}
+	var expr strings.Builder
+	last := 0
+	for _, m := range placeholder.FindAllStringSubmatchIndex(ep.Path, -1) {
+		expr.WriteString(regexp.QuoteMeta(ep.Path[last:m[0]]))
+		expr.WriteString("([^/]*)")
+		c.names = append(c.names, ep.Path[m[2]:m[3]])
+		last = m[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(ep.Path[last:]) + "$")
+	c.path = regexp.MustCompile(expr.String())
+	return c
+}
+
+func (c *requestChecker) check(r *http.Request) error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if m := c.path.FindStringSubmatch(r.URL.Path); m != nil {
+		values := make(map[string]string, len(c.names))
+		for i, name := range c.names {
+			values[name] = m[i+1]
+		}
+		for _, p := range c.ep.URIParameters {
+			if v, ok := values[p.Key]; ok {
+				if err := raml.CheckParameter(p.Definition, v); err != nil {
+					add("URI parameter %s: %s", p.Key, err)
+				}
+			}
+		}
+	}
+
+	query := r.URL.Query()
+	for _, p := range c.ep.QueryParameters {
+		values, ok := query[p.Key]
+		if !ok {
+			if p.Required {
+				add("missing query parameter %s", p.Key)
+			}
+			continue
+		}
+		for _, v := range values {
+			if err := raml.CheckParameter(p.Definition, v); err != nil {
+				add("query parameter %s: %s", p.Key, err)
+			}
+		}
+	}
+
+	for _, p := range c.ep.Headers {
+		values := headerValues(r.Header, p.Key)
+		if len(values) == 0 {
+			if p.Required {
+				add("missing header %s", p.Key)
+			}
+			continue
+		}
+		for _, v := range values {
+			if err := raml.CheckParameter(p.Definition, v); err != nil {
+				add("header %s: %s", p.Key, err)
+			}
+		}
+	}
+
+	if len(c.ep.FormParameters) > 0 {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if isForm(mediaType) {
+			problems = append(problems, c.checkForm(r)...)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &RequestError{problems}
+	}
+	return nil
+}
+
+func (c *requestChecker) checkForm(r *http.Request) []string {
+	err := r.ParseMultipartForm(maxFormMemory)
+	if err == http.ErrNotMultipart {
+		err = r.ParseForm()
+	}
+	if err != nil {
+		return []string{"invalid form: " + err.Error()}
+	}
+
+	var problems []string
+	for _, p := range c.ep.FormParameters {
+		if p.Type == "file" {
+			if r.MultipartForm == nil || len(r.MultipartForm.File[p.Key]) == 0 {
+				if p.Required {
+					problems = append(problems, "missing form file "+p.Key)
+				}
+			}
+			continue
+		}
+		values, ok := r.PostForm[p.Key]
+		if !ok {
+			if p.Required {
+				problems = append(problems, "missing form parameter "+p.Key)
+			}
+			continue
+		}
+		for _, v := range values {
+			if err := raml.CheckParameter(p.Definition, v); err != nil {
+				problems = append(problems, fmt.Sprintf("form parameter %s: %s", p.Key, err))
+			}
+		}
+	}
+	return problems
+}
+
+// headerValues returns the values of the named header, matching its name
+// case-insensitively even if h wasn't built with canonical keys.
+func headerValues(h http.Header, name string) []string {
+	if values := h.Values(name); len(values) > 0 {
+		return values
+	}
+	for key, values := range h {
+		if strings.EqualFold(key, name) {
+			return values
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package ramlapi
+
+import (
+	"errors"
+
+	"github.com/buddhamagnet/raml"
+)
+
+// Resource is a resource in an API's tree, with the endpoints of its
+// methods and its nested resources.
+type Resource struct {
+	// Path is the full path of the resource, as on its endpoints, and
+	// RelativePath the part declared on the resource itself.
+	Path         string `json:"path"`
+	RelativePath string `json:"relativePath"`
+
+	DisplayName string `json:"displayName,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// URIParameters are the resource's URI parameters, including those
+	// inherited from its parents.
+	URIParameters []*Parameter `json:"uriParameters,omitempty"`
+
+	Endpoints []*Endpoint `json:"endpoints,omitempty"`
+	Children  []*Resource `json:"children,omitempty"`
+
+	// Parent is the resource this one is nested in, or nil at the top.
+	Parent *Resource `json:"-"`
+
+	// Resource is the RAML resource the Resource was built from.
+	Resource *raml.Resource `json:"-"`
+}
+
+// SkipChildren is returned by the function passed to Walk to skip the
+// children of a resource.
+var SkipChildren = errors.New("skip children")
+
+// Resources returns the top-level resources of an API, sorted by path,
+// with their endpoints built as Build builds them. Options are as for
+// Build, but nothing is logged.
+func Resources(api *raml.APIDefinition, opts ...Option) ([]*Resource, error) {
+	return newOptions(opts).resources(api)
+}
+
+// Walk calls fn for each resource of an API, parents before their
+// children and siblings sorted by path. If fn returns SkipChildren the
+// resource's children are skipped; any other error stops the walk and is
+// returned. Options are as for Build, but nothing is logged.
+func Walk(api *raml.APIDefinition, fn func(r *Resource) error, opts ...Option) error {
+	resources, err := Resources(api, opts...)
+	if err != nil {
+		return err
+	}
+	return walk(resources, fn)
+}
+
+func walk(resources []*Resource, fn func(r *Resource) error) error {
+	for _, r := range resources {
+		err := fn(r)
+		if err == SkipChildren {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := walk(r.Children, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resources builds the resource tree of an API.
+func (o *options) resources(api *raml.APIDefinition) ([]*Resource, error) {
+	// Endpoints carry what their methods inherit, including methods only
+	// resource types declare
+	api = raml.Expanded(api)
+
+	o.mediaType = api.MediaType
+	o.protocols = apiProtocols(api)
+	o.securitySchemes = make(map[string]*raml.SecurityScheme)
+	for _, named := range api.SecuritySchemes {
+		for name, scheme := range named {
+			scheme := scheme
+			o.securitySchemes[name] = &scheme
+		}
+	}
+
+	var resources []*Resource
+	for _, name := range sortedKeys(api.Resources) {
+		resource := api.Resources[name]
+		var baseParams []*Parameter
+		prefix := o.basePath
+		params := baseURIParams(api, &resource)
+		for _, name := range sortedKeys(params) {
+			param := params[name]
+			baseParams = append(baseParams, newParam(name, &param))
+		}
+		if o.baseURI {
+			prefix += basePath(api, params, o.baseURIValues)
+		}
+		r, err := processResource(nil, prefix, "", name, &resource, nil, baseParams, api.SecuredBy, o)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, r)
+	}
+	return resources, nil
+}
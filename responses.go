@@ -0,0 +1,146 @@
+package ramlapi
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/buddhamagnet/raml"
+)
+
+// ResponseMode says what EnforceResponses does with a response that
+// breaks the spec.
+type ResponseMode int
+
+const (
+	// ReportMode reports the problems and sends the response as written.
+	ReportMode ResponseMode = iota
+
+	// StrictMode reports the problems and sends a 500 instead of the
+	// response.
+	StrictMode
+)
+
+// ResponseError lists the ways a response breaks its endpoint's spec.
+type ResponseError struct {
+	Endpoint *Endpoint
+	Request  *http.Request
+	Status   int
+	Problems []string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%s %s: %d: %s", e.Request.Method, e.Request.URL.Path, e.Status, strings.Join(e.Problems, "; "))
+}
+
+// EnforceResponses checks the responses next writes against an endpoint:
+// the status code must be one of its declared responses, and the headers
+// declared for that response must be set with valid values. Problems are
+// passed to report, or logged if report is nil. In StrictMode the response
+// is buffered so it can be replaced with a 500 listing the problems.
+func EnforceResponses(ep *Endpoint, next http.Handler, mode ResponseMode, report func(*ResponseError)) http.Handler {
+	if report == nil {
+		report = func(err *ResponseError) { log.Println(err) }
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{w: w, ep: ep, r: r, report: report, strict: mode == StrictMode}
+		if rw.strict {
+			rw.header = make(http.Header)
+		}
+		next.ServeHTTP(rw, r)
+		if !rw.checked {
+			rw.WriteHeader(http.StatusOK)
+		}
+		if !rw.strict {
+			return
+		}
+
+		if rw.err != nil {
+			http.Error(w, "response breaks the spec:\n"+strings.Join(rw.err.Problems, "\n"), http.StatusInternalServerError)
+			return
+		}
+		for k, v := range rw.header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(rw.status)
+		w.Write(rw.body.Bytes())
+	})
+}
+
+// checkResponse returns the problems with a response's status and headers.
+func checkResponse(ep *Endpoint, status int, header http.Header) []string {
+	if ep.Method == nil || len(ep.Method.Responses) == 0 {
+		return nil
+	}
+	response, ok := ep.Method.Responses[raml.HTTPCode(status)]
+	if !ok {
+		return []string{fmt.Sprintf("undeclared status %d", status)}
+	}
+	var problems []string
+	for _, name := range sortedHeaderNames(response.Headers) {
+		values := headerValues(header, name)
+		if len(values) == 0 {
+			problems = append(problems, "missing header "+name)
+			continue
+		}
+		for _, v := range values {
+			if err := raml.CheckParameter(raml.NamedParameter(response.Headers[raml.HTTPHeader(name)]), v); err != nil {
+				problems = append(problems, fmt.Sprintf("header %s: %s", name, err))
+			}
+		}
+	}
+	return problems
+}
+
+// responseWriter checks the status and headers of a response when they
+// are written, buffering the response in strict mode.
+type responseWriter struct {
+	w      http.ResponseWriter
+	ep     *Endpoint
+	r      *http.Request
+	report func(*ResponseError)
+	strict bool
+
+	checked bool
+	err     *ResponseError
+
+	// In strict mode only
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *responseWriter) Header() http.Header {
+	if rw.strict {
+		return rw.header
+	}
+	return rw.w.Header()
+}
+
+func (rw *responseWriter) WriteHeader(status int) {
+	if rw.checked {
+		return
+	}
+	rw.checked = true
+	if problems := checkResponse(rw.ep, status, rw.Header()); len(problems) > 0 {
+		rw.err = &ResponseError{rw.ep, rw.r, status, problems}
+		rw.report(rw.err)
+	}
+	if rw.strict {
+		rw.status = status
+		return
+	}
+	rw.w.WriteHeader(status)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if !rw.checked {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if rw.strict {
+		return rw.body.Write(b)
+	}
+	return rw.w.Write(b)
+}
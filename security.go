@@ -0,0 +1,159 @@
+package ramlapi
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/buddhamagnet/raml"
+)
+
+// Security is a security scheme an endpoint is secured by.
+type Security struct {
+	// Name is the scheme's name, or "null" if the endpoint may be called
+	// without credentials.
+	Name string `json:"name"`
+
+	// Type is the scheme's type, such as "Basic Authentication",
+	// "OAuth 2.0" or a custom "x-" type.
+	Type string `json:"type,omitempty"`
+
+	// Scopes are the OAuth scopes the endpoint requires.
+	Scopes []string `json:"scopes,omitempty"`
+
+	// Scheme is the scheme as declared in the RAML, or nil for "null" and
+	// undeclared schemes.
+	Scheme *raml.SecurityScheme `json:"-"`
+}
+
+// security resolves securedBy against the API's security schemes.
+func (o *options) security(securedBy []raml.DefinitionChoice) []*Security {
+	var list []*Security
+	for _, choice := range securedBy {
+		s := &Security{Name: choice.Name}
+		if s.Name == "" {
+			s.Name = "null"
+		}
+		if scheme, ok := o.securitySchemes[s.Name]; ok {
+			s.Type = scheme.Type
+			s.Scheme = scheme
+		}
+		if scopes, ok := choice.Parameters["scopes"]; ok {
+			s.Scopes = scopes.Values()
+		}
+		list = append(list, s)
+	}
+	return list
+}
+
+// An Authenticator checks a request's credentials for a security scheme,
+// returning false if they are missing or wrong.
+type Authenticator func(r *http.Request, s *Security) bool
+
+// BasicAuth authenticates "Basic Authentication" schemes with check.
+func BasicAuth(check func(user, password string) bool) Authenticator {
+	return func(r *http.Request, s *Security) bool {
+		user, password, ok := r.BasicAuth()
+		return ok && check(user, password)
+	}
+}
+
+// BearerAuth authenticates "OAuth 2.0" schemes by passing the bearer token
+// from the Authorization header, and the scopes the endpoint requires, to
+// check.
+func BearerAuth(check func(token string, scopes []string) bool) Authenticator {
+	return func(r *http.Request, s *Security) bool {
+		auth := r.Header.Get("Authorization")
+		if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+			return false
+		}
+		token := strings.TrimSpace(auth[7:])
+		return token != "" && check(token, s.Scopes)
+	}
+}
+
+// KeyAuth authenticates custom "x-" schemes, such as API keys, by passing
+// the credential to check. The credential is read from the first header
+// the scheme's describedBy declares, or failing that its first query
+// parameter.
+func KeyAuth(check func(key string) bool) Authenticator {
+	return func(r *http.Request, s *Security) bool {
+		if s.Scheme == nil {
+			return false
+		}
+		var key string
+		if headers := sortedHeaderNames(s.Scheme.DescribedBy.Headers); len(headers) > 0 {
+			key = r.Header.Get(headers[0])
+		} else if params := sortedKeys(s.Scheme.DescribedBy.QueryParameters); len(params) > 0 {
+			key = r.URL.Query().Get(params[0])
+		}
+		return key != "" && check(key)
+	}
+}
+
+type contextKey int
+
+const (
+	securityKey contextKey = iota
+	negotiatedKey
+)
+
+// SecurityFrom returns the scheme that authorized a request passed by
+// Secure, or nil if the request was let through without credentials.
+func SecurityFrom(ctx context.Context) *Security {
+	s, _ := ctx.Value(securityKey).(*Security)
+	return s
+}
+
+// Secure enforces an endpoint's SecuredBy before calling next. Each scheme
+// is checked by the authenticator keyed by its name or, failing that, its
+// type, and the first to accept the request's credentials is available to
+// next from SecurityFrom. Requests no scheme accepts get a 401, so schemes
+// without an authenticator are never satisfied. A "null" scheme lets
+// requests without credentials through.
+func Secure(ep *Endpoint, next http.Handler, auth map[string]Authenticator) http.Handler {
+	if len(ep.SecuredBy) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		optional := false
+		for _, s := range ep.SecuredBy {
+			if s.Name == "null" {
+				optional = true
+				continue
+			}
+			check, ok := auth[s.Name]
+			if !ok {
+				check = auth[s.Type]
+			}
+			if check != nil && check(r, s) {
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), securityKey, s)))
+				return
+			}
+		}
+		if optional {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		for _, s := range ep.SecuredBy {
+			switch s.Type {
+			case "Basic Authentication":
+				w.Header().Add("WWW-Authenticate", `Basic realm="`+s.Name+`"`)
+			case "OAuth 2.0":
+				w.Header().Add("WWW-Authenticate", "Bearer")
+			}
+		}
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	})
+}
+
+func sortedHeaderNames(headers map[raml.HTTPHeader]raml.Header) []string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,196 @@
+package ramlapi
+
+import (
+	"fmt"
+	"math"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+
+	"github.com/buddhamagnet/raml"
+)
+
+// SampleValue returns a value satisfying the constraints of a parameter.
+// It prefers the parameter's example, then its default and enum values,
+// before generating one from its type, pattern, length and range. It
+// returns false if it can't find a valid value.
+func SampleValue(param raml.NamedParameter) (string, bool) {
+	var candidates []string
+	if param.Example != "" {
+		candidates = append(candidates, param.Example)
+	}
+	if param.Default != nil {
+		candidates = append(candidates, fmt.Sprint(param.Default))
+	}
+	for _, e := range param.Enum {
+		candidates = append(candidates, fmt.Sprint(e))
+	}
+
+	switch param.Type {
+	case "integer", "number":
+		switch {
+		case param.Minimum != nil:
+			candidates = append(candidates, formatNumber(math.Ceil(*param.Minimum)), formatNumber(*param.Minimum))
+		case param.Maximum != nil && *param.Maximum < 1:
+			candidates = append(candidates, formatNumber(math.Floor(*param.Maximum)), formatNumber(*param.Maximum))
+		default:
+			candidates = append(candidates, "1")
+		}
+	case "boolean":
+		candidates = append(candidates, "true")
+	case "date":
+		candidates = append(candidates, "Sun, 06 Nov 1994 08:49:37 GMT")
+	default:
+		if param.Pattern != nil {
+			if s, ok := matchingString(*param.Pattern); ok {
+				candidates = append(candidates, s)
+			}
+		}
+		n := 1
+		if param.MinLength != nil && *param.MinLength > n {
+			n = *param.MinLength
+		}
+		candidates = append(candidates, strings.Repeat("a", n))
+	}
+
+	for _, c := range candidates {
+		if raml.CheckParameter(param, c) == nil {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+// InvalidValue is a value breaking a parameter's constraints.
+type InvalidValue struct {
+	Value string
+
+	// Reason names the constraint broken: type, enum, pattern, minLength,
+	// maxLength, minimum or maximum.
+	Reason string
+}
+
+// InvalidValues returns values that each break one of a parameter's
+// constraints. A parameter with no constraints, such as an unrestricted
+// string, has no invalid values.
+func InvalidValues(param raml.NamedParameter) []InvalidValue {
+	var candidates []InvalidValue
+	switch param.Type {
+	case "integer":
+		candidates = append(candidates, InvalidValue{"1.5", "type"}, InvalidValue{"one", "type"})
+	case "number":
+		candidates = append(candidates, InvalidValue{"one", "type"})
+	case "boolean":
+		candidates = append(candidates, InvalidValue{"yes", "type"})
+	case "date":
+		candidates = append(candidates, InvalidValue{"yesterday", "type"})
+	}
+	if len(param.Enum) > 0 {
+		if param.Type == "integer" || param.Type == "number" {
+			max := math.Inf(-1)
+			for _, e := range param.Enum {
+				if n, err := strconv.ParseFloat(fmt.Sprint(e), 64); err == nil && n > max {
+					max = n
+				}
+			}
+			if !math.IsInf(max, -1) {
+				candidates = append(candidates, InvalidValue{formatNumber(math.Floor(max) + 1), "enum"})
+			}
+		}
+		candidates = append(candidates, InvalidValue{"not-" + fmt.Sprint(param.Enum[0]), "enum"})
+	}
+	if param.Pattern != nil {
+		for _, s := range []string{"!", "~~~", " ", "0"} {
+			candidates = append(candidates, InvalidValue{s, "pattern"})
+		}
+	}
+	if param.MinLength != nil && *param.MinLength > 0 {
+		candidates = append(candidates, InvalidValue{strings.Repeat("a", *param.MinLength-1), "minLength"})
+	}
+	if param.MaxLength != nil {
+		candidates = append(candidates, InvalidValue{strings.Repeat("a", *param.MaxLength+1), "maxLength"})
+	}
+	if param.Minimum != nil {
+		candidates = append(candidates, InvalidValue{formatNumber(math.Floor(*param.Minimum) - 1), "minimum"})
+	}
+	if param.Maximum != nil {
+		candidates = append(candidates, InvalidValue{formatNumber(math.Ceil(*param.Maximum) + 1), "maximum"})
+	}
+
+	// Keep one value per reason, and only values that really are invalid
+	var invalid []InvalidValue
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		if !seen[c.Reason] && raml.CheckParameter(param, c.Value) != nil {
+			seen[c.Reason] = true
+			invalid = append(invalid, c)
+		}
+	}
+	return invalid
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// matchingString returns the shortest string matching a pattern, taking
+// the first choice of any alternation or character class.
+func matchingString(pattern string) (string, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	var b strings.Builder
+	if !writeMatch(&b, re.Simplify()) {
+		return "", false
+	}
+	return b.String(), true
+}
+
+func writeMatch(b *strings.Builder, re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine,
+		syntax.OpBeginText, syntax.OpEndText, syntax.OpWordBoundary,
+		syntax.OpNoWordBoundary, syntax.OpStar, syntax.OpQuest:
+		return true
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+		return true
+	case syntax.OpCharClass:
+		if len(re.Rune) == 0 {
+			return false
+		}
+		// Prefer a letter or digit within the first range
+		lo, hi := re.Rune[0], re.Rune[1]
+		for _, r := range "a0A" {
+			if r >= lo && r <= hi {
+				lo = r
+				break
+			}
+		}
+		b.WriteRune(lo)
+		return true
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteRune('a')
+		return true
+	case syntax.OpCapture, syntax.OpPlus:
+		return writeMatch(b, re.Sub[0])
+	case syntax.OpRepeat:
+		for i := 0; i < re.Min; i++ {
+			if !writeMatch(b, re.Sub[0]) {
+				return false
+			}
+		}
+		return true
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if !writeMatch(b, sub) {
+				return false
+			}
+		}
+		return true
+	case syntax.OpAlternate:
+		return writeMatch(b, re.Sub[0])
+	}
+	return false
+}